@@ -3,8 +3,8 @@ package main
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
+	stdlog "log"
 	"net/http"
 	"os"
 	"os/signal"
@@ -13,22 +13,34 @@ import (
 
 	"github.com/go-chi/chi"
 	"github.com/go-chi/cors"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	httpSwagger "github.com/swaggo/http-swagger"
 	"go.uber.org/zap"
-
-	_ "github.com/jackc/pgx/v5/stdlib"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 
 	_ "github.com/Jexim/HelloGo/docs"
 	httpadapter "github.com/Jexim/HelloGo/internal/adapter/http"
+	"github.com/Jexim/HelloGo/internal/adapter/http/admin"
 	httpmw "github.com/Jexim/HelloGo/internal/adapter/http/middleware"
 
 	// restrespond "github.com/Jexim/HelloGo/internal/rest/respond"
 	"github.com/Jexim/HelloGo/internal/modules/hello"
+	"github.com/Jexim/HelloGo/internal/platform/auth"
+	"github.com/Jexim/HelloGo/internal/platform/cache"
+	"github.com/Jexim/HelloGo/internal/platform/certs"
+	"github.com/Jexim/HelloGo/internal/platform/concurrency"
 	"github.com/Jexim/HelloGo/internal/platform/config"
 	platformdb "github.com/Jexim/HelloGo/internal/platform/db"
 	"github.com/Jexim/HelloGo/internal/platform/logger"
+	"github.com/Jexim/HelloGo/internal/platform/metrics"
+	"github.com/Jexim/HelloGo/internal/platform/migrate"
+	"github.com/Jexim/HelloGo/internal/platform/ratelimit"
+	"github.com/Jexim/HelloGo/internal/platform/redact"
 	"github.com/Jexim/HelloGo/internal/platform/sentry"
+	"github.com/Jexim/HelloGo/internal/platform/tracing"
 )
 
 // @title Hello Service API
@@ -41,37 +53,103 @@ func main() {
 	// Load config
 	cfg := config.Load()
 
-	// Init logger
-	log := logger.New(cfg.Logger.Level)
+	// Create context that will be canceled on system interrupt
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Init logger, masking secrets and personal data
+	redactor, err := redact.New(cfg.Redaction)
+	if err != nil {
+		stdlog.Fatalf("invalid redaction config: %v", err)
+	}
+	log, level, err := logger.New(ctx, cfg.Logger, redactor)
+	if err != nil {
+		stdlog.Fatalf("failed to initialize logger: %v", err)
+	}
 	defer log.Sync()
+	// Code without a request-scoped logger falls back to the global one
+	zap.ReplaceGlobals(log)
 
 	// Initialize Sentry
-	if err := sentry.Init(cfg.Sentry.DSN, cfg.Sentry.Environment, log); err != nil {
+	if err := sentry.Init(cfg.Sentry.DSN, cfg.Sentry.Environment, redactor, log); err != nil {
 		log.Fatal("failed to initialize sentry", zap.Error(err))
 	}
 	defer sentry.Flush(2 * time.Second)
 
-	// Create context that will be canceled on system interrupt
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
-	defer stop()
+	// Initialize OpenTelemetry tracing
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		log.Fatal("failed to initialize tracing", zap.Error(err))
+	}
+
+	// Run a subcommand such as "migrate up" instead of the server
+	if len(os.Args) > 1 {
+		if err := runCommand(ctx, cfg, log, os.Args[1:]); err != nil {
+			log.Fatal("command failed", zap.Strings("args", os.Args[1:]), zap.Error(err))
+		}
+		return
+	}
+
+	// Every metric of the service is registered here, apart from the global
+	// registry so that nothing registers into it by accident
+	promReg := prometheus.NewRegistry()
+	m := metrics.New(promReg, cfg.Metrics)
 
 	// Setup multiple DBs via registry
-	mainDB, reg, err := setupDatabases(cfg, log)
+	reg, resolver, err := setupDatabases(ctx, cfg, m, log)
 	if err != nil {
 		log.Fatal("failed to setup database(s)", zap.Error(err))
 	}
 	defer reg.Close()
+	reg.Monitor(ctx)
+	// Export pool statistics of every primary and replica
+	promReg.MustRegister(platformdb.NewStatsCollector(reg))
+
+	// Apply pending migrations before serving traffic
+	if cfg.Database.AutoMigrate && cfg.Database.Driver != platformdb.DriverMemory {
+		if err := migrate.NewRunner(reg, migrationSets(cfg), routing(cfg), log).Up(ctx); err != nil {
+			log.Fatal("failed to apply migrations", zap.Error(err))
+		}
+	}
+
+	// SIGUSR1 switches to debug logs for a while, or back when sent again
+	levels := logger.NewController(level, log)
+	usr1 := make(chan os.Signal, 1)
+	signal.Notify(usr1, syscall.SIGUSR1)
+	defer signal.Stop(usr1)
+	go func() {
+		for range usr1 {
+			levels.Toggle(zapcore.DebugLevel, cfg.Logger.DebugDuration)
+		}
+	}()
 
 	// Setup HTTP server
-	server, err := setupServer(cfg, mainDB, log)
+	server, adminServer, err := setupServer(ctx, cfg, reg, resolver, levels, promReg, m, log)
 	if err != nil {
 		log.Fatal("failed to setup server", zap.Error(err))
 	}
 
+	// Start the admin listener in a goroutine
+	if adminServer != nil {
+		go func() {
+			log.Info("starting admin server", zap.String("address", cfg.Admin.Address))
+			if err := adminServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Fatal("failed to start admin server", zap.Error(err))
+			}
+		}()
+	}
+
 	// Start server in a goroutine
 	go func() {
-		log.Info("starting server", zap.String("address", cfg.Server.Address))
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Info("starting server", zap.String("address", cfg.Server.Address), zap.Bool("tls", cfg.Server.TLS.Enabled()), zap.Bool("h2c", cfg.Server.H2C))
+		var err error
+		if server.TLSConfig != nil {
+			// Certificates come from TLSConfig, which follows file changes
+			err = server.ListenAndServeTLS("", "")
+		} else {
+			err = server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			log.Fatal("failed to start server", zap.Error(err))
 		}
 	}()
@@ -81,51 +159,268 @@ func main() {
 	log.Info("shutting down server...")
 
 	// Create shutdown context with timeout
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
 	// Attempt graceful shutdown
 	if err := server.Shutdown(shutdownCtx); err != nil {
 		log.Error("server forced to shutdown", zap.Error(err))
 	}
+	if adminServer != nil {
+		if err := adminServer.Shutdown(shutdownCtx); err != nil {
+			log.Error("admin server forced to shutdown", zap.Error(err))
+		}
+	}
+
+	// Export the spans still buffered
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		log.Error("failed to flush traces", zap.Error(err))
+	}
 
 	log.Info("server stopped")
 }
 
-func setupDatabases(cfg *config.Config, log *zap.Logger) (*sql.DB, *platformdb.Registry, error) {
-	dsnMap := make(map[string]string, len(cfg.Databases))
-	for name, dc := range cfg.Databases {
-		dsnMap[name] = dc.URI
+// migrationSets lists the migrations of every module and of the platform stores enabled in cfg
+func migrationSets(cfg *config.Config) []migrate.Set {
+	sets := []migrate.Set{
+		hello.Migrations(),
+	}
+	if cfg.RateLimit.Enabled && cfg.RateLimit.Store == ratelimit.StorePostgres {
+		sets = append(sets, ratelimit.Migrations(cfg.RateLimit.Database))
+	}
+	return sets
+}
+
+// runCommand executes a CLI subcommand
+func runCommand(ctx context.Context, cfg *config.Config, log *zap.Logger, args []string) error {
+	switch args[0] {
+	case "migrate":
+		reg, err := platformdb.OpenAll(ctx, cfg.Databases, log)
+		if err != nil {
+			return err
+		}
+		defer reg.Close()
+		return migrate.NewRunner(reg, migrationSets(cfg), routing(cfg), log).Run(ctx, args[1:], os.Stdout)
+	case "schema":
+		if len(args) < 2 || args[1] != "check" {
+			return fmt.Errorf("usage: schema check")
+		}
+		return checkSchema(ctx, cfg)
+	default:
+		return fmt.Errorf("unknown command %q", args[0])
 	}
-	reg, err := platformdb.OpenAll(dsnMap)
+}
+
+// checkSchema diffs each module's migrations against its sqlc schema on the main database
+// and fails on drift, so deploys can be gated on it
+func checkSchema(ctx context.Context, cfg *config.Config) error {
+	dc, ok := cfg.Databases[platformdb.DefaultName]
+	if !ok {
+		return fmt.Errorf("main database is not configured")
+	}
+	// The sqlc schema is Postgres; SQLite migrations double as their sqlc schema
+	if dc.Driver != "" && dc.Driver != platformdb.DriverPgx {
+		return fmt.Errorf("schema check needs a Postgres main database, got driver %q", dc.Driver)
+	}
+	drifted := []string{}
+	for _, set := range migrationSets(cfg) {
+		fmt.Fprintf(os.Stdout, "== %s\n", set.Module)
+		// Platform stores such as the rate limiter keep no sqlc schema to compare with
+		if set.Schema == nil {
+			fmt.Fprintln(os.Stdout, "no sqlc schema, skipped")
+			continue
+		}
+		ok, err := migrate.CheckDrift(ctx, dc.URI, set, os.Stdout)
+		if err != nil {
+			return fmt.Errorf("module %s: %w", set.Module, err)
+		}
+		if !ok {
+			drifted = append(drifted, set.Module)
+		}
+	}
+	if len(drifted) > 0 {
+		return fmt.Errorf("schema drift in %v", drifted)
+	}
+	fmt.Fprintln(os.Stdout, "no schema drift")
+	return nil
+}
+
+// routing maps tenants and modules to their registry databases
+func routing(cfg *config.Config) platformdb.Routing {
+	r := platformdb.Routing{
+		Tenants: cfg.Tenant.Databases,
+		Modules: make(map[string]string, len(cfg.Modules)),
+	}
+	for name, mc := range cfg.Modules {
+		if mc.Database != "" {
+			r.Modules[name] = mc.Database
+		}
+	}
+	return r
+}
+
+func setupDatabases(ctx context.Context, cfg *config.Config, m *metrics.Metrics, log *zap.Logger) (*platformdb.Registry, *platformdb.Resolver, error) {
+	// The memory driver runs with an empty registry and no resolver
+	if cfg.Database.Driver == platformdb.DriverMemory {
+		log.Warn("running without a database, data is kept in memory")
+		reg, err := platformdb.OpenAll(ctx, nil, log)
+		return reg, nil, err
+	}
+
+	// All databases share one startup deadline instead of each waiting its own timeout
+	connectCtx, cancel := context.WithTimeout(ctx, connectTimeout(cfg))
+	defer cancel()
+	reg, err := platformdb.OpenAll(connectCtx, cfg.Databases, log)
 	if err != nil {
 		return nil, nil, err
 	}
-	mainDB := reg.Get("main")
-	if mainDB == nil {
-		return nil, reg, fmt.Errorf("main database is not configured")
+	if reg.Get(platformdb.DefaultName) == nil {
+		return reg, nil, fmt.Errorf("main database is not configured")
 	}
-	return mainDB, reg, nil
+
+	// Route tenants and modules to their registry databases
+	ins := platformdb.NewInstrumenter(m, log, cfg.Database.SlowQueryThreshold)
+	resolver, err := platformdb.NewResolver(reg, routing(cfg), ins)
+	if err != nil {
+		return reg, nil, err
+	}
+
+	log.Info("databases ready", zap.Strings("databases", reg.Names()))
+	return reg, resolver, nil
+}
+
+// connectTimeout is the longest connect timeout of the registry databases;
+// like the db package, it takes an unset timeout as a minute
+func connectTimeout(cfg *config.Config) time.Duration {
+	var timeout time.Duration
+	for _, dc := range cfg.Databases {
+		if dc.Connect.Timeout <= 0 {
+			dc.Connect.Timeout = time.Minute
+		}
+		timeout = max(timeout, dc.Connect.Timeout)
+	}
+	return timeout
 }
 
-func setupServer(cfg *config.Config, db *sql.DB, log *zap.Logger) (*http.Server, error) {
+// stickyWindow is the longest sticky window of the registry databases
+func stickyWindow(cfg *config.Config) time.Duration {
+	var window time.Duration
+	for _, dc := range cfg.Databases {
+		window = max(window, dc.StickyWindow)
+	}
+	return window
+}
+
+// checkPriorities rejects priority classes the limiter does not know
+func checkPriorities(cc config.ConcurrencyConfig) error {
+	if _, ok := concurrency.ParsePriority(cc.DefaultPriority); !ok {
+		return fmt.Errorf("unknown default priority %q", cc.DefaultPriority)
+	}
+	for _, rc := range cc.Routes {
+		if _, ok := concurrency.ParsePriority(rc.Priority); !ok {
+			return fmt.Errorf("route %s %s: unknown priority %q", rc.Method, rc.Pattern, rc.Priority)
+		}
+	}
+	return nil
+}
+
+// rateLimitStore returns the bucket store configured by rc
+func rateLimitStore(rc config.RateLimitConfig, reg *platformdb.Registry, log *zap.Logger) (ratelimit.Store, error) {
+	switch rc.Store {
+	case "", ratelimit.StoreMemory:
+		return ratelimit.NewMemory(), nil
+	case ratelimit.StorePostgres:
+		if reg.Driver(rc.Database) != platformdb.DriverPgx {
+			return nil, fmt.Errorf("rate limit store: database %s is not a Postgres database", rc.Database)
+		}
+		return ratelimit.NewPostgres(reg.Get(rc.Database), log), nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit store %q", rc.Store)
+	}
+}
+
+// setupServer builds the public server and, when it is enabled, the admin
+// server exporting promReg, on which m is registered
+func setupServer(ctx context.Context, cfg *config.Config, reg *platformdb.Registry, resolver *platformdb.Resolver, levels *logger.Controller, promReg *prometheus.Registry, m *metrics.Metrics, log *zap.Logger) (*http.Server, *http.Server, error) {
 	mux := chi.NewRouter()
 
 	// Middleware setup
 	mux.Use(cors.New(cors.Options{
 		AllowedOrigins:   []string{"*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-Trace-ID"},
-		ExposedHeaders:   []string{"Link", "X-Trace-ID"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-Trace-ID", "traceparent", "tracestate", "X-API-Key", "X-Tenant-ID", "X-Debug-Log", "If-None-Match", "If-Modified-Since"},
+		ExposedHeaders:   []string{"Link", "X-Trace-ID", "traceparent", "tracestate", "ETag", "Last-Modified", "X-Cache", "Retry-After", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy"},
 		AllowCredentials: false,
 		MaxAge:           300,
 	}).Handler)
 
+	// Take the client address from trusted proxies' X-Forwarded-For
+	proxies, err := httpmw.ParseTrustedProxies(cfg.Server.TrustedProxies)
+	if err != nil {
+		return nil, nil, err
+	}
+	mux.Use(httpmw.TrustedProxies(proxies))
+
 	// Add trace ID middleware
 	mux.Use(httpmw.TraceID(log))
 
+	// Log every request with the logger TraceID scoped to it
+	if cfg.Logger.AccessLog.Enabled {
+		mux.Use(httpmw.AccessLog(cfg.Logger.AccessLog))
+	}
+
+	// Reject oversized request bodies
+	mux.Use(httpmw.MaxBodyBytes(cfg.Server.MaxBodyBytes))
+
 	// Metrics middleware
-	mux.Use(httpmw.Metrics)
+	mux.Use(httpmw.Metrics(m))
+
+	// Shed load beyond the adaptive concurrency limit, sparing health and metrics
+	if cc := cfg.Server.Concurrency; cc.Enabled {
+		if err := checkPriorities(cc); err != nil {
+			return nil, nil, err
+		}
+		limiter := concurrency.NewLimiter(concurrency.Options{
+			InitialLimit:     cc.InitialLimit,
+			MinLimit:         cc.MinLimit,
+			MaxLimit:         cc.MaxLimit,
+			LatencyThreshold: cc.LatencyThreshold,
+			Backoff:          cc.Backoff,
+			MaxQueue:         cc.MaxQueue,
+			QueueTimeout:     cc.QueueTimeout,
+		}, m)
+		mux.Use(httpmw.ConcurrencyLimit(cc, limiter, mux, "/health", "/livez", "/readyz", cfg.Metrics.Path))
+	}
+
+	// Throttle each client IP, whatever credentials it sends
+	var limitStore ratelimit.Store
+	if cfg.RateLimit.Enabled {
+		if limitStore, err = rateLimitStore(cfg.RateLimit, reg, log); err != nil {
+			return nil, nil, err
+		}
+		if cfg.RateLimit.IPBurst > 0 {
+			ipLimit := ratelimit.Limit{Rate: cfg.RateLimit.IPRate, Burst: cfg.RateLimit.IPBurst}
+			mux.Use(httpmw.RateLimitIP(ipLimit, limitStore, mux, m, log))
+		}
+	}
+
+	// Resolve the calling principal from API keys
+	mux.Use(httpmw.Authenticate(cfg.Auth.APIKeys, cfg.Auth.AnonymousScopes, cfg.Tenant.Claim))
+	mux.Use(httpmw.ClientCertificate(cfg.Server.TLS.Clients, cfg.Auth.AnonymousScopes, cfg.Tenant.Claim))
+
+	// Let authorized callers ask for debug logs of their request
+	mux.Use(httpmw.DebugLog(cfg.Logger.DebugScopes, log))
+
+	// Throttle each client to its quota
+	if cfg.RateLimit.Enabled {
+		mux.Use(httpmw.RateLimit(cfg.RateLimit, limitStore, mux, m, log))
+	}
+
+	// Resolve the tenant every database access is scoped to
+	mux.Use(httpmw.Tenant(cfg.Tenant))
+
+	// Keep reads after a write on the primary, in the client's next requests too
+	mux.Use(httpmw.ReadYourWrites(stickyWindow(cfg)))
 
 	// Error middleware with Sentry capture
 	mux.Use(httpmw.ErrorHandler(log, func(err error) {
@@ -135,34 +430,108 @@ func setupServer(cfg *config.Config, db *sql.DB, log *zap.Logger) (*http.Server,
 		}
 	}))
 
+	// Setup REST handlers
+	az := auth.NewAuthorizer(log)
+
+	// Operational endpoints live on the admin listener when it is enabled,
+	// otherwise on the public router with /admin guarded by the admin scope
+	ops, opsGuarded := chi.Router(mux), chi.Router(mux)
+	adminGuard := httpmw.RequireScope(az, "admin", admin.ScopeAdmin)
+	var adminMux *chi.Mux
+	if cfg.Admin.Enabled {
+		guard, err := admin.Guard(cfg.Admin, cfg.Auth.APIKeys, az)
+		if err != nil {
+			return nil, nil, err
+		}
+		adminMux = chi.NewRouter()
+		adminMux.Use(httpmw.TraceID(log))
+		if cfg.Logger.AccessLog.Enabled {
+			adminMux.Use(httpmw.AccessLog(cfg.Logger.AccessLog))
+		}
+		ops, opsGuarded, adminGuard = adminMux, adminMux.With(guard), guard
+		admin.Pprof(opsGuarded)
+	}
+
 	// Swagger documentation
-	mux.Get("/swagger/*", httpSwagger.Handler(
+	opsGuarded.Get("/swagger/*", httpSwagger.Handler(
 		httpSwagger.URL("/swagger/doc.json"),
 	))
 
 	// Metrics endpoint
 	if cfg.Metrics.Enabled {
-		mux.Handle(cfg.Metrics.Path, promhttp.Handler())
+		// OpenMetrics is the format that carries exemplars
+		opsGuarded.Handle(cfg.Metrics.Path, promhttp.HandlerFor(promReg, promhttp.HandlerOpts{
+			Registry:          promReg,
+			EnableOpenMetrics: true,
+		}))
 	}
 
-	// Setup REST handlers
-	_, err := httpadapter.New(httpadapter.InitArgs{
-		Logger: log,
-		DB:     db,
-		Router: mux,
+	// HTTP response cache, purged by writes and the admin endpoint
+	var rc *httpmw.ResponseCache
+	if cfg.HTTPCache.Enabled {
+		store, err := cache.New(httpmw.CacheName, cfg.HTTPCache, m)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create http cache: %w", err)
+		}
+		rc = httpmw.NewResponseCache(store, cache.TTL(cfg.HTTPCache), m)
+	}
+	admin.New(ops, "/admin", adminGuard, admin.Args{Config: cfg, Level: levels, Cache: rc, Logger: log})
+
+	helloOpts := hello.DatastoreOptions{Driver: cfg.Database.Driver, Cache: cfg.Modules[hello.Module].Cache, Metrics: m}
+	if resolver != nil {
+		helloOpts.Driver = resolver.Driver(hello.Module)
+		helloOpts.Source = resolver.For(hello.Module)
+	}
+	helloDS, err := hello.NewDatastore(helloOpts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create hello datastore: %w", err)
+	}
+	_, err = httpadapter.New(httpadapter.InitArgs{
+		Logger:    log,
+		Metrics:   m,
+		DBs:       reg,
+		Router:    mux,
+		OpsRouter: ops,
 	}, httpadapter.ArgsREST{
-		Hello: hello.NewREST(mux, "/api/v1/hello", hello.NewUsecase(hello.NewDatastore(db))),
+		Hello: hello.NewREST(mux, "/api/v1/hello", hello.NewUsecase(helloDS, hello.NewPolicy(az)), az, rc),
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create main REST: %w", err)
+		return nil, nil, fmt.Errorf("failed to create main REST: %w", err)
 	}
 
 	// Create server with timeouts
-	return &http.Server{
-		Addr:         cfg.Server.Address,
-		Handler:      mux,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
-	}, nil
+	sc := cfg.Server
+	server := &http.Server{
+		Addr:              sc.Address,
+		Handler:           mux,
+		ReadTimeout:       sc.ReadTimeout,
+		ReadHeaderTimeout: sc.ReadHeaderTimeout,
+		WriteTimeout:      sc.WriteTimeout,
+		IdleTimeout:       sc.IdleTimeout,
+		MaxHeaderBytes:    sc.MaxHeaderBytes,
+	}
+	if sc.TLS.Enabled() {
+		reloader, err := certs.NewReloader(sc.TLS, log)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load TLS certificates: %w", err)
+		}
+		if err := reloader.Watch(ctx); err != nil {
+			return nil, nil, fmt.Errorf("failed to watch TLS certificates: %w", err)
+		}
+		server.TLSConfig = reloader.TLSConfig()
+	} else if sc.H2C {
+		server.Handler = h2c.NewHandler(mux, &http2.Server{IdleTimeout: sc.IdleTimeout})
+	}
+
+	if adminMux == nil {
+		return server, nil, nil
+	}
+	// No write timeout, CPU profiles and traces stream for as long as requested
+	adminServer := &http.Server{
+		Addr:              cfg.Admin.Address,
+		Handler:           adminMux,
+		ReadHeaderTimeout: sc.ReadHeaderTimeout,
+		IdleTimeout:       sc.IdleTimeout,
+	}
+	return server, adminServer, nil
 }
@@ -33,6 +33,101 @@ const docTemplate = `{
                         }
                     }
                 }
+            },
+            "post": {
+                "description": "Create a Hello authored by the caller",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Create Hello",
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.Hello"
+                        }
+                    }
+                }
+            }
+        },
+        "/hello/{id}": {
+            "get": {
+                "description": "Get a single Hello",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get Hello by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Hello ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Hello"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Update the message of a Hello; only its author or an admin may do so",
+                "consumes": [
+                    "application/json"
+                ],
+                "summary": "Update Hello",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Hello ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": ""
+                    },
+                    "403": {
+                        "description": ""
+                    },
+                    "404": {
+                        "description": ""
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a Hello; only its author or an admin may do so",
+                "summary": "Delete Hello",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Hello ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": ""
+                    },
+                    "403": {
+                        "description": ""
+                    },
+                    "404": {
+                        "description": ""
+                    }
+                }
             }
         }
     },
@@ -41,6 +136,9 @@ const docTemplate = `{
             "description": "Hello",
             "type": "object",
             "properties": {
+                "author": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "integer"
                 },
@@ -0,0 +1,183 @@
+package admin
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	httpmw "github.com/Jexim/HelloGo/internal/adapter/http/middleware"
+	httprespond "github.com/Jexim/HelloGo/internal/adapter/http/respond"
+	"github.com/Jexim/HelloGo/internal/platform/apperr"
+	"github.com/Jexim/HelloGo/internal/platform/auth"
+	"github.com/Jexim/HelloGo/internal/platform/config"
+	"github.com/Jexim/HelloGo/internal/platform/logger"
+)
+
+const (
+	// ScopeAdmin grants access to the admin endpoints
+	ScopeAdmin = "admin"
+)
+
+const (
+	// AuthNone leaves the admin listener open, e.g. when it is bound to localhost
+	AuthNone = "none"
+	// AuthToken requires "Authorization: Bearer <token>"
+	AuthToken = "token"
+	// AuthAPIKey requires an auth API key holding ScopeAdmin
+	AuthAPIKey = "api_key"
+)
+
+type REST struct {
+	config *config.Config
+	level  *logger.Controller
+	cache  *httpmw.ResponseCache
+	logger *zap.Logger
+}
+
+// Args are the runtime handles the admin endpoints operate on
+type Args struct {
+	Config *config.Config
+	// Level controls the logger's level through /loglevel
+	Level *logger.Controller
+	// Cache is purged through /cache/purge when it is set
+	Cache  *httpmw.ResponseCache
+	Logger *zap.Logger
+}
+
+type levelRequest struct {
+	Level string `json:"level"`
+	// Duration such as "10m" reverts the change once it passes
+	Duration string `json:"duration,omitempty"`
+}
+
+type purgeRequest struct {
+	// Keys are the surrogate keys to purge; empty purges every cached response
+	Keys []string `json:"keys"`
+}
+
+// New registers the admin endpoints under path, each behind guard
+func New(r chi.Router, path string, guard func(http.Handler) http.Handler, args Args) *REST {
+	rest := &REST{
+		config: args.Config,
+		level:  args.Level,
+		cache:  args.Cache,
+		logger: args.Logger,
+	}
+
+	r.Route(path, func(r chi.Router) {
+		r.Use(guard)
+		r.Get("/config", rest.Config)
+		r.Get("/loglevel", rest.LogLevel)
+		r.Put("/loglevel", rest.SetLogLevel)
+		if args.Cache != nil {
+			r.Post("/cache/purge", rest.PurgeCache)
+		}
+	})
+
+	return rest
+}
+
+// Guard returns the middleware enforcing the auth setting of the admin listener
+func Guard(cfg config.AdminConfig, keys []config.APIKeyConfig, az *auth.Authorizer) (func(http.Handler) http.Handler, error) {
+	switch cfg.Auth {
+	case AuthNone:
+		return func(next http.Handler) http.Handler { return next }, nil
+	case "", AuthToken:
+		if cfg.Token == "" {
+			return nil, fmt.Errorf("admin auth %q needs a token", AuthToken)
+		}
+		return bearerToken(cfg.Token), nil
+	case AuthAPIKey:
+		authenticate := httpmw.Authenticate(keys, nil, "")
+		requireAdmin := httpmw.RequireScope(az, "admin", ScopeAdmin)
+		return func(next http.Handler) http.Handler {
+			return authenticate(requireAdmin(next))
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown admin auth %q", cfg.Auth)
+	}
+}
+
+// Pprof registers the net/http/pprof handlers under /debug/pprof
+func Pprof(r chi.Router) {
+	r.HandleFunc("/debug/pprof/*", pprof.Index)
+	r.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	r.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	r.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	r.HandleFunc("/debug/pprof/trace", pprof.Trace)
+}
+
+// Config writes the effective configuration with secrets redacted
+func (r *REST) Config(w http.ResponseWriter, req *http.Request) {
+	httprespond.JSON(w, http.StatusOK, r.config.Redacted())
+}
+
+// LogLevel reports the runtime log level
+func (r *REST) LogLevel(w http.ResponseWriter, req *http.Request) {
+	httprespond.JSON(w, http.StatusOK, r.level.State())
+}
+
+// SetLogLevel changes the runtime log level with {"level":"debug","duration":"10m"},
+// where duration is optional
+func (r *REST) SetLogLevel(w http.ResponseWriter, req *http.Request) {
+	var body levelRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		httpmw.RespondError(w, req, fmt.Errorf("%w: invalid body", apperr.ErrBadRequest))
+		return
+	}
+	var l zapcore.Level
+	if err := l.Set(body.Level); err != nil {
+		httpmw.RespondError(w, req, fmt.Errorf("%w: unknown level %q", apperr.ErrBadRequest, body.Level))
+		return
+	}
+	var d time.Duration
+	if body.Duration != "" {
+		var err error
+		if d, err = time.ParseDuration(body.Duration); err != nil || d < 0 {
+			httpmw.RespondError(w, req, fmt.Errorf("%w: invalid duration %q", apperr.ErrBadRequest, body.Duration))
+			return
+		}
+	}
+	r.level.Set(l, d)
+	httprespond.JSON(w, http.StatusOK, r.level.State())
+}
+
+// PurgeCache invalidates cached HTTP responses by surrogate key
+func (r *REST) PurgeCache(w http.ResponseWriter, req *http.Request) {
+	var body purgeRequest
+	if req.ContentLength != 0 {
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			httpmw.RespondError(w, req, fmt.Errorf("%w: invalid body", apperr.ErrBadRequest))
+			return
+		}
+	}
+	if err := r.cache.Purge(req.Context(), body.Keys...); err != nil {
+		httpmw.RespondError(w, req, err)
+		return
+	}
+	r.logger.Info("http cache purged", zap.Strings("keys", body.Keys), zap.String("trace_id", httpmw.GetTraceID(req)))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// bearerToken rejects requests without "Authorization: Bearer <token>"
+func bearerToken(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := r.Header.Get("Authorization")
+			if len(h) < 7 || !strings.EqualFold(h[:7], "bearer ") ||
+				subtle.ConstantTimeCompare([]byte(strings.TrimSpace(h[7:])), []byte(token)) != 1 {
+				httpmw.RespondError(w, r, apperr.ErrUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
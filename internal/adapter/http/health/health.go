@@ -53,3 +53,30 @@ func (r *REST) Handler() http.HandlerFunc {
 		}
 	}
 }
+
+// Live answers liveness probes; the process serving it is alive
+func (r *REST) Live() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok\n"))
+	}
+}
+
+// Ready answers readiness probes with 503 while the primary of a required
+// database is down. Optional databases and replicas being unavailable leave
+// the service ready but degraded.
+func (r *REST) Ready() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx, cancel := context.WithTimeout(req.Context(), 5*time.Second)
+		defer cancel()
+
+		for name, s := range r.checker.Check(ctx).Services {
+			if s.Status == "error" {
+				http.Error(w, name+" is down", http.StatusServiceUnavailable)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok\n"))
+	}
+}
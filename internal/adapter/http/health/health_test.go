@@ -0,0 +1,75 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/Jexim/HelloGo/internal/platform/config"
+	platformdb "github.com/Jexim/HelloGo/internal/platform/db"
+	"github.com/Jexim/HelloGo/internal/platform/health"
+	"github.com/Jexim/HelloGo/internal/platform/metrics"
+)
+
+func TestReady(t *testing.T) {
+	dir := t.TempDir()
+	// SQLite cannot create a file in a directory that does not exist
+	dead := filepath.Join(dir, "missing", "dead.db")
+
+	cases := []struct {
+		name string
+		db   config.DatabaseConfig
+		// down closes the primary after startup
+		down   bool
+		status int
+	}{
+		{
+			name:   "healthy primary",
+			db:     config.DatabaseConfig{URI: filepath.Join(dir, "ok.db")},
+			status: http.StatusOK,
+		},
+		{
+			name:   "dead replica of a required database",
+			db:     config.DatabaseConfig{URI: filepath.Join(dir, "primary.db"), Replicas: []string{dead}},
+			status: http.StatusOK,
+		},
+		{
+			name:   "dead optional primary",
+			db:     config.DatabaseConfig{URI: dead, Optional: true},
+			status: http.StatusOK,
+		},
+		{
+			name:   "required primary down",
+			db:     config.DatabaseConfig{URI: filepath.Join(dir, "down.db")},
+			down:   true,
+			status: http.StatusServiceUnavailable,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			c.db.Driver = platformdb.DriverSQLite
+			reg, err := platformdb.OpenAll(context.Background(), map[string]config.DatabaseConfig{platformdb.DefaultName: c.db}, zap.NewNop())
+			if err != nil {
+				t.Fatalf("OpenAll: %v", err)
+			}
+			t.Cleanup(func() { _ = reg.Close() })
+			if c.down {
+				_ = reg.Get(platformdb.DefaultName).Close()
+			}
+
+			checker := health.NewChecker(reg, metrics.New(prometheus.NewRegistry(), config.MetricsConfig{}), zap.NewNop())
+			rest := &REST{checker: checker, logger: zap.NewNop()}
+			rec := httptest.NewRecorder()
+			rest.Ready()(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			if rec.Code != c.status {
+				t.Fatalf("Ready = %d %q, want %d", rec.Code, strings.TrimSpace(rec.Body.String()), c.status)
+			}
+		})
+	}
+}
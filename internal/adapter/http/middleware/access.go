@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/go-chi/chi"
+	"go.uber.org/zap"
+
+	"github.com/Jexim/HelloGo/internal/platform/config"
+	"github.com/Jexim/HelloGo/internal/platform/logger"
+)
+
+// AccessLog logs every request once it is served with the request-scoped
+// logger of TraceID, which it must run after. Requests to cfg.Exclude paths
+// are skipped; of the rest, cfg.SampleRate are logged, and every 5xx response.
+func AccessLog(cfg config.AccessLogConfig) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if slices.Contains(cfg.Exclude, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			start := time.Now()
+			rw := &countingWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rw, r)
+
+			failed := rw.status >= http.StatusInternalServerError
+			if !failed && cfg.SampleRate < 1 && rand.Float64() >= cfg.SampleRate {
+				return
+			}
+			route := ""
+			if rc := chi.RouteContext(r.Context()); rc != nil {
+				route = rc.RoutePattern()
+			}
+			fields := []zap.Field{
+				zap.String("method", r.Method),
+				zap.String("route", route),
+				zap.String("path", r.URL.Path),
+				zap.Int("status", rw.status),
+				zap.Int64("bytes", rw.bytes),
+				zap.Duration("latency", time.Since(start)),
+				zap.String("client_ip", ClientIP(r)),
+				zap.String("user_agent", r.UserAgent()),
+			}
+			log := logger.FromContext(r.Context())
+			if failed {
+				log.Error("http_request", fields...)
+				return
+			}
+			log.Info("http_request", fields...)
+		})
+	}
+}
+
+// countingWriter captures the status code and the number of body bytes written
+type countingWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *countingWriter) WriteHeader(code int) {
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *countingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
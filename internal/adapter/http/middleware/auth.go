@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/Jexim/HelloGo/internal/platform/apperr"
+	"github.com/Jexim/HelloGo/internal/platform/auth"
+	"github.com/Jexim/HelloGo/internal/platform/config"
+)
+
+const (
+	APIKeyHeader = "X-API-Key"
+)
+
+// Authenticate resolves the caller from an API key and stores the principal in the request context.
+// Requests without credentials get an anonymous principal holding anonymousScopes.
+// The tenant of a key is stored as the claim named tenantClaim, which Tenant
+// reads; an empty tenantClaim drops it.
+func Authenticate(keys []config.APIKeyConfig, anonymousScopes []string, tenantClaim string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := apiKeyFromRequest(r)
+			if key == "" {
+				ctx := auth.WithPrincipal(r.Context(), &auth.Principal{Scopes: anonymousScopes})
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			for _, k := range keys {
+				if subtle.ConstantTimeCompare([]byte(k.Key), []byte(key)) == 1 {
+					p := &auth.Principal{Subject: k.Subject, Scopes: k.Scopes}
+					if k.Tenant != "" && tenantClaim != "" {
+						p.Claims = map[string]string{tenantClaim: k.Tenant}
+					}
+					ctx := auth.WithPrincipal(r.Context(), p)
+					next.ServeHTTP(w, r.WithContext(ctx))
+					return
+				}
+			}
+
+			RespondError(w, r, apperr.ErrUnauthorized)
+		})
+	}
+}
+
+// RequireScope is a route guard that rejects callers holding none of scopes with 403
+func RequireScope(az *auth.Authorizer, action string, scopes ...string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := az.RequireScope(r.Context(), action, scopes...); err != nil {
+				RespondError(w, r, err)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// apiKeyFromRequest reads the key from X-API-Key or an "Authorization: Bearer" header
+func apiKeyFromRequest(r *http.Request) string {
+	if k := r.Header.Get(APIKeyHeader); k != "" {
+		return k
+	}
+	if h := r.Header.Get("Authorization"); len(h) > 7 && strings.EqualFold(h[:7], "bearer ") {
+		return strings.TrimSpace(h[7:])
+	}
+	return ""
+}
@@ -0,0 +1,327 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Jexim/HelloGo/internal/platform/auth"
+	"github.com/Jexim/HelloGo/internal/platform/cache"
+	"github.com/Jexim/HelloGo/internal/platform/metrics"
+	"github.com/Jexim/HelloGo/internal/platform/tenant"
+)
+
+const (
+	// CacheName labels the cache metrics of HTTP responses
+	CacheName = "http"
+	// CacheStatusHeader tells whether a response was served from the cache
+	CacheStatusHeader = "X-Cache"
+)
+
+// surrogateAll is the surrogate key every cached response carries
+const surrogateAll = "*"
+
+// CachePolicy is the caching behaviour of one route
+type CachePolicy struct {
+	// MaxAge is how long clients may reuse a response; zero makes them revalidate every time
+	MaxAge time.Duration
+	// Private keeps shared caches such as CDNs from storing the response
+	Private bool
+	// Vary lists request headers that select a different response
+	Vary []string
+	// SurrogateKeys tags the response so writes can purge it
+	SurrogateKeys func(r *http.Request) []string
+}
+
+// cacheControl returns the Cache-Control header value of the policy
+func (p CachePolicy) cacheControl() string {
+	visibility := "public"
+	if p.Private {
+		visibility = "private"
+	}
+	if p.MaxAge <= 0 {
+		return visibility + ", no-cache"
+	}
+	return fmt.Sprintf("%s, max-age=%d", visibility, int(p.MaxAge.Seconds()))
+}
+
+type cachedResponse struct {
+	Header       http.Header       `json:"header"`
+	Body         []byte            `json:"body"`
+	ETag         string            `json:"etag"`
+	LastModified time.Time         `json:"last_modified"`
+	Generations  map[string]string `json:"generations"`
+}
+
+// ResponseCache stores GET responses keyed by normalized URL, tenant and the
+// caller's scopes. Every response is tagged with surrogate keys; purging a key
+// replaces its generation, which invalidates all responses stored under the old one.
+// Generations are the times of their purge, so the latest generation of a
+// response's keys is when its data last changed, which it is served with as
+// Last-Modified. A nil ResponseCache only sets the policy headers.
+type ResponseCache struct {
+	store   cache.Store
+	ttl     time.Duration
+	metrics *metrics.Metrics
+}
+
+func NewResponseCache(store cache.Store, ttl time.Duration, m *metrics.Metrics) *ResponseCache {
+	return &ResponseCache{store: store, ttl: ttl, metrics: m}
+}
+
+// Cache applies p to GET requests of a route and serves them from the cache,
+// answering If-None-Match and If-Modified-Since with 304
+func (c *ResponseCache) Cache(p CachePolicy) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet {
+				next.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Set("Cache-Control", p.cacheControl())
+			if len(p.Vary) > 0 {
+				w.Header().Set("Vary", strings.Join(p.Vary, ", "))
+			}
+			if c == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx := r.Context()
+			key := responseKey(r, p.Vary)
+			surrogates := append([]string{surrogateAll}, surrogateKeys(p, r)...)
+			if b, ok, err := c.store.Get(ctx, key); err == nil && ok {
+				var entry cachedResponse
+				if json.Unmarshal(b, &entry) == nil && c.current(ctx, entry.Generations) {
+					c.metrics.CacheHits.WithLabelValues(CacheName).Inc()
+					w.Header().Set(CacheStatusHeader, "HIT")
+					serveCached(w, r, &entry)
+					return
+				}
+			}
+			c.metrics.CacheMisses.WithLabelValues(CacheName).Inc()
+			w.Header().Set(CacheStatusHeader, "MISS")
+
+			// Generations are read before the handler runs, so a purge racing with
+			// it leaves the stored response on the old generation
+			gens, err := c.generations(ctx, surrogates)
+			rec := &bufferedResponse{header: http.Header{}, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+			if rec.status != http.StatusOK {
+				w.Header().Set("Cache-Control", "no-store")
+				rec.flush(w)
+				return
+			}
+
+			sum := sha256.Sum256(rec.body.Bytes())
+			entry := &cachedResponse{
+				Header:       rec.header,
+				Body:         rec.body.Bytes(),
+				ETag:         `"` + hex.EncodeToString(sum[:16]) + `"`,
+				LastModified: lastModified(gens),
+				Generations:  gens,
+			}
+			if err == nil {
+				if b, err := json.Marshal(entry); err == nil {
+					_ = c.store.Set(ctx, key, b, c.ttl)
+				}
+			}
+			serveCached(w, r, entry)
+		})
+	}
+}
+
+// Invalidate purges the surrogate keys returned by keys after the route answers with 2xx
+func (c *ResponseCache) Invalidate(keys func(r *http.Request) []string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if c == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			rw := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rw, r)
+			if rw.status >= 200 && rw.status < 300 {
+				_ = c.Purge(r.Context(), keys(r)...)
+			}
+		})
+	}
+}
+
+// Purge invalidates every response tagged with one of keys, or all responses when keys is empty
+func (c *ResponseCache) Purge(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		keys = []string{surrogateAll}
+	}
+	gen := newGeneration()
+	for _, k := range keys {
+		if err := c.store.Set(ctx, generationKey(k), []byte(gen), c.ttl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// generations returns the current generation of every surrogate key, starting missing ones
+func (c *ResponseCache) generations(ctx context.Context, keys []string) (map[string]string, error) {
+	gens := make(map[string]string, len(keys))
+	for _, k := range keys {
+		b, ok, err := c.store.Get(ctx, generationKey(k))
+		if err != nil {
+			return nil, err
+		}
+		gen := string(b)
+		if !ok {
+			gen = newGeneration()
+			if err := c.store.Set(ctx, generationKey(k), []byte(gen), c.ttl); err != nil {
+				return nil, err
+			}
+		}
+		gens[k] = gen
+	}
+	return gens, nil
+}
+
+// current reports whether no surrogate key of a stored response was purged since
+func (c *ResponseCache) current(ctx context.Context, gens map[string]string) bool {
+	if _, ok := gens[surrogateAll]; !ok {
+		return false
+	}
+	for k, gen := range gens {
+		b, ok, err := c.store.Get(ctx, generationKey(k))
+		if err != nil || !ok || string(b) != gen {
+			return false
+		}
+	}
+	return true
+}
+
+// newGeneration returns a generation of the current time
+func newGeneration() string {
+	return strconv.FormatInt(time.Now().UnixNano(), 36)
+}
+
+// lastModified returns the time of the latest of gens; keys without a purge
+// since the cache started count from when they were first seen
+func lastModified(gens map[string]string) time.Time {
+	var latest time.Time
+	for _, gen := range gens {
+		if n, err := strconv.ParseInt(gen, 36, 64); err == nil && time.Unix(0, n).After(latest) {
+			latest = time.Unix(0, n)
+		}
+	}
+	if latest.IsZero() {
+		latest = time.Now()
+	}
+	return latest.UTC().Truncate(time.Second)
+}
+
+// serveCached writes entry, or 304 when the request's validators still match it
+func serveCached(w http.ResponseWriter, r *http.Request, entry *cachedResponse) {
+	h := w.Header()
+	h.Set("ETag", entry.ETag)
+	h.Set("Last-Modified", entry.LastModified.Format(http.TimeFormat))
+	if notModified(r, entry) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	for k, v := range entry.Header {
+		h[k] = v
+	}
+	h.Set("Content-Length", strconv.Itoa(len(entry.Body)))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(entry.Body)
+}
+
+// notModified evaluates If-None-Match, or If-Modified-Since when it is absent
+func notModified(r *http.Request, entry *cachedResponse) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == "*" || tag == entry.ETag {
+				return true
+			}
+		}
+		return false
+	}
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" {
+		t, err := http.ParseTime(ims)
+		return err == nil && !entry.LastModified.After(t)
+	}
+	return false
+}
+
+// responseKey identifies a response by normalized URL, tenant, the caller's
+// scopes and the values of the other vary headers
+func responseKey(r *http.Request, vary []string) string {
+	q := r.URL.Query()
+	for k, v := range q {
+		sort.Strings(v)
+		if len(v) == 1 && v[0] == "" {
+			delete(q, k)
+		}
+	}
+	var scopes []string
+	if p := auth.FromContext(r.Context()); p != nil {
+		scopes = append(scopes, p.Scopes...)
+		sort.Strings(scopes)
+	}
+	parts := []string{
+		path.Clean("/" + r.URL.Path),
+		q.Encode(),
+		tenant.FromContext(r.Context()),
+		strings.Join(scopes, " "),
+	}
+	for _, h := range vary {
+		// Credentials are represented by the scopes they grant, so callers
+		// with the same scopes share responses
+		if h = http.CanonicalHeaderKey(h); h == "Authorization" || h == http.CanonicalHeaderKey(APIKeyHeader) {
+			continue
+		}
+		parts = append(parts, r.Header.Get(h))
+	}
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\n")))
+	return CacheName + ":response:" + hex.EncodeToString(sum[:])
+}
+
+func surrogateKeys(p CachePolicy, r *http.Request) []string {
+	if p.SurrogateKeys == nil {
+		return nil
+	}
+	return p.SurrogateKeys(r)
+}
+
+func generationKey(surrogate string) string {
+	return CacheName + ":surrogate:" + surrogate
+}
+
+// bufferedResponse holds a handler's response until it is known whether to cache it
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header { return b.header }
+
+func (b *bufferedResponse) WriteHeader(code int) { b.status = code }
+
+func (b *bufferedResponse) Write(p []byte) (int, error) { return b.body.Write(p) }
+
+// flush writes the buffered response to w unchanged
+func (b *bufferedResponse) flush(w http.ResponseWriter) {
+	for k, v := range b.header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(b.status)
+	_, _ = w.Write(b.body.Bytes())
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+
+	"github.com/Jexim/HelloGo/internal/platform/concurrency"
+	"github.com/Jexim/HelloGo/internal/platform/config"
+)
+
+// ConcurrencyLimit admits requests through limiter by the priority class of
+// their route and answers shed requests with 503 and Retry-After. Responses
+// with a 5xx status count as failed, which backs the limit off like slow ones.
+// Paths in exempt, such as health and metrics, are never limited nor shed.
+func ConcurrencyLimit(cfg config.ConcurrencyConfig, limiter *concurrency.Limiter, routes chi.Routes, exempt ...string) func(next http.Handler) http.Handler {
+	def, _ := concurrency.ParsePriority(cfg.DefaultPriority)
+	retryAfter := ceilSeconds(cfg.RetryAfter)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, p := range exempt {
+				if r.URL.Path == p {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			priority := def
+			pattern := matchRoute(routes, r)
+			for _, rc := range cfg.Routes {
+				if rc.Pattern == pattern && (rc.Method == "" || strings.EqualFold(rc.Method, r.Method)) {
+					priority, _ = concurrency.ParsePriority(rc.Priority)
+					break
+				}
+			}
+
+			release, err := limiter.Acquire(r.Context(), priority)
+			if err != nil {
+				if errors.Is(err, concurrency.ErrShed) {
+					w.Header().Set("Retry-After", retryAfter)
+					writeError(w, r, http.StatusServiceUnavailable, "overloaded", "server is overloaded, retry later")
+				}
+				return
+			}
+			start := time.Now()
+			rw := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			defer func() { release(time.Since(start), rw.status >= http.StatusInternalServerError) }()
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/Jexim/HelloGo/internal/platform/concurrency"
+	"github.com/Jexim/HelloGo/internal/platform/config"
+	"github.com/Jexim/HelloGo/internal/platform/metrics"
+)
+
+func TestConcurrencyLimit(t *testing.T) {
+	cfg := config.ConcurrencyConfig{
+		RetryAfter:      2 * time.Second,
+		DefaultPriority: "normal",
+		Routes:          []config.RoutePriorityConfig{{Pattern: "/critical", Priority: "critical"}},
+	}
+	cases := []struct {
+		path   string
+		status int
+	}{
+		{"/livez", http.StatusOK},
+		{"/readyz", http.StatusOK},
+		{"/metrics", http.StatusOK},
+		{"/critical", http.StatusOK},
+		{"/api", http.StatusServiceUnavailable},
+	}
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			// The only slot is taken, so limited requests wait out the queue timeout
+			limiter := concurrency.NewLimiter(concurrency.Options{InitialLimit: 1, MaxLimit: 1, MaxQueue: 1, QueueTimeout: 10 * time.Millisecond}, testMetrics())
+			hold, err := limiter.Acquire(context.Background(), concurrency.PriorityNormal)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer hold(0, false)
+
+			mux := chi.NewRouter()
+			mux.Use(ConcurrencyLimit(cfg, limiter, mux, "/livez", "/readyz", "/metrics"))
+			for _, route := range cases {
+				mux.Get(route.path, func(w http.ResponseWriter, r *http.Request) {})
+			}
+
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, c.path, nil))
+			if rec.Code != c.status {
+				t.Fatalf("status = %d, want %d", rec.Code, c.status)
+			}
+			if c.status == http.StatusServiceUnavailable {
+				if got := rec.Header().Get("Retry-After"); got != "2" {
+					t.Fatalf("Retry-After = %q, want 2", got)
+				}
+			}
+		})
+	}
+}
+
+func TestConcurrencyLimitBacksOffOnServerErrors(t *testing.T) {
+	limiter := concurrency.NewLimiter(concurrency.Options{InitialLimit: 10, MaxLimit: 10, Backoff: 0.5}, testMetrics())
+	h := ConcurrencyLimit(config.ConcurrencyConfig{}, limiter, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if got := limiter.Limit(); got != 5 {
+		t.Fatalf("limit = %d after a 502, want 5", got)
+	}
+}
+
+func testMetrics() *metrics.Metrics {
+	return metrics.New(prometheus.NewRegistry(), config.MetricsConfig{})
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	platformdb "github.com/Jexim/HelloGo/internal/platform/db"
+)
+
+// LastWriteCookie carries the time of a client's last write, in Unix
+// milliseconds, to its following requests
+const LastWriteCookie = "last_write"
+
+// ReadYourWrites makes reads that follow a write go to the primary, within the
+// same request and, through LastWriteCookie, in the client's requests during
+// the following window. Cookies claiming a write in the future are ignored.
+func ReadYourWrites(window time.Duration) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var since time.Time
+			if c, err := r.Cookie(LastWriteCookie); err == nil && window > 0 {
+				if ms, err := strconv.ParseInt(c.Value, 10, 64); err == nil && ms <= time.Now().UnixMilli() {
+					since = time.UnixMilli(ms)
+				}
+			}
+			r = r.WithContext(platformdb.WithStickiness(r.Context(), since))
+			if window <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(&lastWriteRecorder{ResponseWriter: w, r: r, since: since, window: window}, r)
+		})
+	}
+}
+
+// lastWriteRecorder sets LastWriteCookie before the response headers go out
+// when the request wrote
+type lastWriteRecorder struct {
+	http.ResponseWriter
+	r           *http.Request
+	since       time.Time
+	window      time.Duration
+	wroteHeader bool
+}
+
+func (l *lastWriteRecorder) WriteHeader(code int) {
+	if !l.wroteHeader {
+		l.wroteHeader = true
+		if t := platformdb.LastWrite(l.r.Context()); t.After(l.since) {
+			http.SetCookie(l.ResponseWriter, &http.Cookie{
+				Name:     LastWriteCookie,
+				Value:    strconv.FormatInt(t.UnixMilli(), 10),
+				Path:     "/",
+				MaxAge:   int(l.window.Round(time.Second).Seconds()) + 1,
+				HttpOnly: true,
+				SameSite: http.SameSiteLaxMode,
+			})
+		}
+	}
+	l.ResponseWriter.WriteHeader(code)
+}
+
+func (l *lastWriteRecorder) Write(b []byte) (int, error) {
+	if !l.wroteHeader {
+		l.WriteHeader(http.StatusOK)
+	}
+	return l.ResponseWriter.Write(b)
+}
@@ -57,15 +57,24 @@ func ErrorHandler(logger *zap.Logger, capture func(err error)) func(http.Handler
 }
 
 func respondError(w http.ResponseWriter, r *http.Request, logger *zap.Logger, status int, code, message string) {
+	writeError(w, r, status, code, message)
+	logger.Error("http_error", zap.Int("status", status), zap.String("code", code), zap.String("message", message), zap.String("trace_id", GetTraceID(r)))
+}
+
+// RespondError writes err as a standard error response using MapError
+func RespondError(w http.ResponseWriter, r *http.Request, err error) {
+	status, code, msg := MapError(err)
+	writeError(w, r, status, code, msg)
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
-	traceID := GetTraceID(r)
 	resp := errorResponse{}
 	resp.Error.Code = code
 	resp.Error.Message = message
-	resp.Error.TraceID = traceID
+	resp.Error.TraceID = GetTraceID(r)
 	_ = json.NewEncoder(w).Encode(resp)
-	logger.Error("http_error", zap.Int("status", status), zap.String("code", code), zap.String("message", message), zap.String("trace_id", traceID))
 }
 
 // MapError maps known errors to HTTP status and codes
@@ -79,6 +88,14 @@ func MapError(err error) (status int, code, msg string) {
 		return http.StatusNotFound, "not_found", err.Error()
 	case errors.Is(err, apperr.ErrAlreadyExists):
 		return http.StatusConflict, "already_exists", err.Error()
+	case errors.Is(err, apperr.ErrUnauthorized):
+		return http.StatusUnauthorized, "unauthorized", err.Error()
+	case errors.Is(err, apperr.ErrForbidden):
+		return http.StatusForbidden, "forbidden", err.Error()
+	case errors.Is(err, apperr.ErrTooLarge):
+		return http.StatusRequestEntityTooLarge, "payload_too_large", err.Error()
+	case errors.Is(err, apperr.ErrRateLimited):
+		return http.StatusTooManyRequests, "rate_limited", err.Error()
 	default:
 		return http.StatusInternalServerError, "internal_error", "internal server error"
 	}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"io"
 	"net/http"
 	"strconv"
 	"time"
@@ -10,40 +11,62 @@ import (
 	"github.com/Jexim/HelloGo/internal/platform/metrics"
 )
 
-// Metrics middleware for tracking HTTP requests
-func Metrics(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-
-		// Create a custom response writer to capture the status code
-		rw := &responseWriter{
-			ResponseWriter: w,
-			statusCode:     http.StatusOK,
-		}
-
-		next.ServeHTTP(rw, r)
-
-		// Record metrics
-		duration := time.Since(start).Seconds()
-		pathLabel := r.URL.Path
-		if rc := chi.RouteContext(r.Context()); rc != nil {
-			if p := rc.RoutePattern(); p != "" {
-				pathLabel = p
+// unmatchedRoute labels requests no route matched, so scans of random
+// paths do not each create a new series
+const unmatchedRoute = "unmatched"
+
+// knownMethods are the methods labeled as sent; others are labeled "OTHER"
+var knownMethods = map[string]bool{
+	http.MethodGet: true, http.MethodHead: true, http.MethodPost: true, http.MethodPut: true,
+	http.MethodPatch: true, http.MethodDelete: true, http.MethodOptions: true,
+}
+
+// Metrics middleware for tracking HTTP requests in m. It runs after TraceID
+// so that duration observations carry the trace id as exemplar.
+func Metrics(m *metrics.Metrics) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			m.HTTPRequestsInFlight.Inc()
+			defer m.HTTPRequestsInFlight.Dec()
+
+			// Count body bytes both ways, as Content-Length is absent when chunked
+			rw := &countingWriter{ResponseWriter: w, status: http.StatusOK}
+			body := &countingReader{ReadCloser: r.Body}
+			if r.Body != nil {
+				r.Body = body
+			}
+
+			next.ServeHTTP(rw, r)
+
+			// Record metrics
+			duration := time.Since(start).Seconds()
+			pathLabel := unmatchedRoute
+			if rc := chi.RouteContext(r.Context()); rc != nil {
+				if p := rc.RoutePattern(); p != "" {
+					pathLabel = p
+				}
+			}
+			method := r.Method
+			if !knownMethods[method] {
+				method = "OTHER"
 			}
-		}
-		metrics.HTTPRequestsTotal.WithLabelValues(r.Method, pathLabel, strconv.Itoa(rw.statusCode)).Inc()
-		metrics.HTTPRequestDuration.WithLabelValues(r.Method, pathLabel).Observe(duration)
-	})
+			m.HTTPRequestsTotal.WithLabelValues(method, pathLabel, strconv.Itoa(rw.status)).Inc()
+			metrics.Observe(r.Context(), m.HTTPRequestDuration.WithLabelValues(method, pathLabel), duration)
+			m.HTTPRequestSize.WithLabelValues(method, pathLabel).Observe(float64(body.bytes))
+			m.HTTPResponseSize.WithLabelValues(method, pathLabel).Observe(float64(rw.bytes))
+		})
+	}
 }
 
-// responseWriter is a custom response writer that captures the status code
-type responseWriter struct {
-	http.ResponseWriter
-	statusCode int
+// countingReader counts the bytes read from a request body
+type countingReader struct {
+	io.ReadCloser
+	bytes int64
 }
 
-// WriteHeader captures the status code
-func (rw *responseWriter) WriteHeader(code int) {
-	rw.statusCode = code
-	rw.ResponseWriter.WriteHeader(code)
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.bytes += int64(n)
+	return n, err
 }
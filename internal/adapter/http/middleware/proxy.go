@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// ForwardedForHeader lists the addresses a request was forwarded for, client first
+const ForwardedForHeader = "X-Forwarded-For"
+
+// ParseTrustedProxies parses proxy addresses and CIDR ranges such as
+// "10.0.0.0/8" or "127.0.0.1"
+func ParseTrustedProxies(proxies []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(proxies))
+	for _, p := range proxies {
+		if !strings.Contains(p, "/") {
+			addr, err := netip.ParseAddr(p)
+			if err != nil {
+				return nil, fmt.Errorf("trusted proxy %q: %w", p, err)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(p)
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxy %q: %w", p, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// TrustedProxies replaces the RemoteAddr of requests sent by one of proxies
+// with the client address in X-Forwarded-For, so ClientIP returns the client
+// rather than the proxy. Entries are read from the right, the last proxy's
+// end, and the first one that is not itself a trusted proxy is the client;
+// anything further left could be forged by it. Requests from other peers keep
+// their RemoteAddr whatever they send.
+func TrustedProxies(proxies []netip.Prefix) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if len(proxies) == 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			peer, ok := parseAddr(ClientIP(r))
+			if !ok || !trusted(proxies, peer) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			forwarded := strings.Split(strings.Join(r.Header.Values(ForwardedForHeader), ","), ",")
+			for i := len(forwarded) - 1; i >= 0; i-- {
+				addr, ok := parseAddr(strings.TrimSpace(forwarded[i]))
+				if !ok {
+					break
+				}
+				if !trusted(proxies, addr) {
+					r = r.WithContext(r.Context())
+					r.RemoteAddr = net.JoinHostPort(addr.String(), "0")
+					break
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func parseAddr(s string) (netip.Addr, bool) {
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+func trusted(proxies []netip.Prefix, addr netip.Addr) bool {
+	for _, p := range proxies {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	"go.uber.org/zap"
+
+	"github.com/Jexim/HelloGo/internal/platform/apperr"
+	"github.com/Jexim/HelloGo/internal/platform/auth"
+	"github.com/Jexim/HelloGo/internal/platform/config"
+	"github.com/Jexim/HelloGo/internal/platform/metrics"
+	"github.com/Jexim/HelloGo/internal/platform/ratelimit"
+)
+
+// RateLimit takes a token from the caller's bucket for every request and
+// rejects the request with 429 once it is empty. Requests to a route with an
+// override in cfg.Routes use a separate bucket with that limit. routes resolves
+// the route pattern, as the middleware runs before chi routes the request.
+// When the store fails the request is let through. Rejections are counted in m.
+func RateLimit(cfg config.RateLimitConfig, store ratelimit.Store, routes chi.Routes, m *metrics.Metrics, logger *zap.Logger) func(next http.Handler) http.Handler {
+	def := ratelimit.Limit{Rate: cfg.Rate, Burst: cfg.Burst}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			pattern := matchRoute(routes, r)
+			limit, bucket := def, "default"
+			for _, rc := range cfg.Routes {
+				if rc.Pattern == pattern && (rc.Method == "" || strings.EqualFold(rc.Method, r.Method)) {
+					limit = ratelimit.Limit{Rate: rc.Rate, Burst: rc.Burst}
+					bucket = rc.Method + " " + rc.Pattern
+					break
+				}
+			}
+
+			if take(w, r, store, "ratelimit:"+clientKey(r)+":"+bucket, limit, pattern, m, logger) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// RateLimitIP takes a token from the bucket of the client IP for every request.
+// It runs before authentication, so requests with made-up credentials are
+// throttled as well.
+func RateLimitIP(limit ratelimit.Limit, store ratelimit.Store, routes chi.Routes, m *metrics.Metrics, logger *zap.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if take(w, r, store, "ratelimit:ip:"+ClientIP(r), limit, matchRoute(routes, r), m, logger) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// take takes a token from the bucket key and reports whether the request may
+// proceed; otherwise it has answered with 429 and counted the rejection in m.
+// The request is let through when the store fails.
+func take(w http.ResponseWriter, r *http.Request, store ratelimit.Store, key string, limit ratelimit.Limit, pattern string, m *metrics.Metrics, logger *zap.Logger) bool {
+	res, err := store.Take(r.Context(), key, limit)
+	if err != nil {
+		logger.Warn("rate limit store failed, allowing request", zap.Error(err), zap.String("trace_id", GetTraceID(r)))
+		return true
+	}
+
+	h := w.Header()
+	h.Set("RateLimit-Limit", strconv.Itoa(limit.Burst))
+	h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	h.Set("RateLimit-Reset", ceilSeconds(res.Reset))
+	h.Set("RateLimit-Policy", rateLimitPolicy(limit))
+	if !res.Allowed {
+		if pattern == "" {
+			pattern = unmatchedRoute
+		}
+		m.RateLimitThrottled.WithLabelValues(r.Method, pattern).Inc()
+		h.Set("Retry-After", ceilSeconds(res.RetryAfter))
+		RespondError(w, r, apperr.ErrRateLimited)
+		return false
+	}
+	return true
+}
+
+// clientKey identifies the caller by API key, else authenticated subject, else client IP
+func clientKey(r *http.Request) string {
+	if key := apiKeyFromRequest(r); key != "" {
+		sum := sha256.Sum256([]byte(key))
+		return "key:" + hex.EncodeToString(sum[:8])
+	}
+	if p := auth.FromContext(r.Context()); p != nil && p.Subject != "" {
+		return "sub:" + p.Subject
+	}
+	return "ip:" + ClientIP(r)
+}
+
+// ClientIP returns the IP address of the peer that sent r, which is the
+// client once TrustedProxies has run
+func ClientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// matchRoute returns the pattern of the route r will be routed to, or an empty string
+func matchRoute(routes chi.Routes, r *http.Request) string {
+	if routes == nil {
+		return ""
+	}
+	rctx := chi.NewRouteContext()
+	if !routes.Match(rctx, r.Method, r.URL.Path) {
+		return ""
+	}
+	return rctx.RoutePattern()
+}
+
+// rateLimitPolicy describes limit as a quota of Burst requests per window in which it refills
+func rateLimitPolicy(limit ratelimit.Limit) string {
+	if limit.Rate <= 0 {
+		return strconv.Itoa(limit.Burst)
+	}
+	window := time.Duration(float64(limit.Burst) / limit.Rate * float64(time.Second))
+	return strconv.Itoa(limit.Burst) + ";w=" + ceilSeconds(window)
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/Jexim/HelloGo/internal/platform/auth"
+	"github.com/Jexim/HelloGo/internal/platform/config"
+	"github.com/Jexim/HelloGo/internal/platform/logger"
+)
+
+// MaxBodyBytes limits request bodies to n bytes; reading past it fails with *http.MaxBytesError
+func MaxBodyBytes(n int64) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if n > 0 && r.Body != nil {
+				r.Body = http.MaxBytesReader(w, r.Body, n)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ClientCertificate authenticates callers by their verified TLS client
+// certificate. It runs after Authenticate and only replaces anonymous
+// principals, so an API key wins over the certificate. Subjects missing from
+// clients get anonymousScopes. The tenant of a client is stored as the claim
+// named tenantClaim, which Tenant reads.
+func ClientCertificate(clients []config.ClientCertConfig, anonymousScopes []string, tenantClaim string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || !auth.FromContext(r.Context()).Anonymous() {
+				next.ServeHTTP(w, r)
+				return
+			}
+			cert := r.TLS.VerifiedChains[0][0]
+			subject := cert.Subject.CommonName
+			if len(cert.URIs) > 0 {
+				subject = cert.URIs[0].String()
+			}
+
+			p := &auth.Principal{
+				Subject: subject,
+				Scopes:  anonymousScopes,
+				Claims:  map[string]string{"auth_method": "mtls", "cert_serial": cert.SerialNumber.String()},
+			}
+			for _, c := range clients {
+				if c.Subject == subject {
+					p.Scopes = c.Scopes
+					if c.Tenant != "" && tenantClaim != "" {
+						p.Claims[tenantClaim] = c.Tenant
+					}
+					break
+				}
+			}
+			next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), p)))
+		})
+	}
+}
+
+// DebugLogHeader asks for a request to be logged at debug level
+const DebugLogHeader = "X-Debug-Log"
+
+// DebugLog honors "X-Debug-Log: 1" from callers holding one of scopes by
+// marking the request context verbose, so loggers obtained through
+// logger.For log it at debug level whatever the runtime level is.
+// The header is ignored for everyone else.
+func DebugLog(scopes []string, log *zap.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get(DebugLogHeader) != "1" || !auth.FromContext(r.Context()).HasScope(scopes...) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			ctx := logger.WithVerbose(r.Context())
+			logger.For(ctx, log).Debug("debug logging requested",
+				zap.String("method", r.Method),
+				zap.String("path", r.URL.Path),
+				zap.String("subject", auth.FromContext(ctx).Subject),
+				zap.String("trace_id", GetTraceID(r)),
+			)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
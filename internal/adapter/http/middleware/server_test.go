@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/Jexim/HelloGo/internal/platform/auth"
+	"github.com/Jexim/HelloGo/internal/platform/config"
+)
+
+func TestClientCertificate(t *testing.T) {
+	spiffe, _ := url.Parse("spiffe://example.org/billing")
+	clients := []config.ClientCertConfig{
+		{Subject: "spiffe://example.org/billing", Scopes: []string{"hello:write"}, Tenant: "acme"},
+		{Subject: "reporting", Scopes: []string{"hello:read"}},
+	}
+	anonymous := []string{"anon"}
+
+	cases := []struct {
+		name      string
+		cert      *x509.Certificate
+		principal *auth.Principal
+		want      *auth.Principal
+	}{
+		{
+			name: "no certificate keeps the principal",
+			want: &auth.Principal{Scopes: anonymous},
+		},
+		{
+			name: "URI SAN wins over common name",
+			cert: &x509.Certificate{SerialNumber: big.NewInt(7), URIs: []*url.URL{spiffe}},
+			want: &auth.Principal{
+				Subject: "spiffe://example.org/billing",
+				Scopes:  []string{"hello:write"},
+				Claims:  map[string]string{"auth_method": "mtls", "cert_serial": "7", "org": "acme"},
+			},
+		},
+		{
+			name: "common name without tenant",
+			cert: testCert("reporting", 8),
+			want: &auth.Principal{
+				Subject: "reporting",
+				Scopes:  []string{"hello:read"},
+				Claims:  map[string]string{"auth_method": "mtls", "cert_serial": "8"},
+			},
+		},
+		{
+			name: "unknown subject gets anonymous scopes",
+			cert: testCert("stranger", 9),
+			want: &auth.Principal{
+				Subject: "stranger",
+				Scopes:  anonymous,
+				Claims:  map[string]string{"auth_method": "mtls", "cert_serial": "9"},
+			},
+		},
+		{
+			name:      "API key principal wins",
+			cert:      testCert("reporting", 10),
+			principal: &auth.Principal{Subject: "ops", Scopes: []string{"admin"}},
+			want:      &auth.Principal{Subject: "ops", Scopes: []string{"admin"}},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if c.cert != nil {
+				r.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{c.cert}}}
+			}
+			p := c.principal
+			if p == nil {
+				p = &auth.Principal{Scopes: anonymous}
+			}
+			r = r.WithContext(auth.WithPrincipal(r.Context(), p))
+
+			var got *auth.Principal
+			h := ClientCertificate(clients, anonymous, "org")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = auth.FromContext(r.Context())
+			}))
+			h.ServeHTTP(httptest.NewRecorder(), r)
+
+			if !reflect.DeepEqual(got, c.want) {
+				t.Fatalf("principal = %+v, want %+v", got, c.want)
+			}
+		})
+	}
+}
+
+func testCert(commonName string, serial int64) *x509.Certificate {
+	cert := &x509.Certificate{SerialNumber: big.NewInt(serial)}
+	cert.Subject.CommonName = commonName
+	return cert
+}
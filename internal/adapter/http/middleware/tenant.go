@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/Jexim/HelloGo/internal/platform/apperr"
+	"github.com/Jexim/HelloGo/internal/platform/auth"
+	"github.com/Jexim/HelloGo/internal/platform/config"
+	"github.com/Jexim/HelloGo/internal/platform/tenant"
+)
+
+// Tenant resolves the tenant of a request and stores it in the context.
+// A tenant claim on the authenticated principal is authoritative; otherwise the
+// tenant header, then the subdomain of cfg.BaseDomain, then cfg.Default are used.
+// Requests whose header names a different tenant than their claim are rejected,
+// and so are header and subdomain selections by callers, anonymous or not,
+// holding none of cfg.SelectScopes.
+// Unresolved requests pass through without a tenant; tenant-scoped database access
+// then fails with tenant.ErrMissing.
+func Tenant(cfg config.TenantConfig) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p := auth.FromContext(r.Context())
+			header := r.Header.Get(cfg.Header)
+			claim := ""
+			if p != nil {
+				claim = p.Claims[cfg.Claim]
+			}
+
+			var tenantID string
+			switch {
+			case claim != "":
+				if header != "" && header != claim {
+					RespondError(w, r, fmt.Errorf("%w: tenant does not match credentials", apperr.ErrForbidden))
+					return
+				}
+				tenantID = claim
+			case header != "":
+				tenantID = header
+			default:
+				tenantID = subdomain(r.Host, cfg.BaseDomain)
+			}
+			if tenantID != "" && claim == "" && !p.HasScope(cfg.SelectScopes...) {
+				RespondError(w, r, fmt.Errorf("%w: caller may not select a tenant", apperr.ErrForbidden))
+				return
+			}
+			if tenantID == "" {
+				tenantID = cfg.Default
+			}
+			if tenantID == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(tenant.WithTenant(r.Context(), tenantID)))
+		})
+	}
+}
+
+// subdomain returns "acme" for host "acme.example.com" and base domain "example.com"
+func subdomain(host, baseDomain string) string {
+	if baseDomain == "" {
+		return ""
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	sub, ok := strings.CutSuffix(strings.ToLower(host), "."+strings.ToLower(baseDomain))
+	if !ok || sub == "" || strings.Contains(sub, ".") {
+		return ""
+	}
+	return sub
+}
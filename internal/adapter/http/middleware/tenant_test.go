@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Jexim/HelloGo/internal/platform/auth"
+	"github.com/Jexim/HelloGo/internal/platform/config"
+	"github.com/Jexim/HelloGo/internal/platform/tenant"
+)
+
+func TestTenant(t *testing.T) {
+	cfg := config.TenantConfig{
+		Header:       "X-Tenant-ID",
+		Claim:        "tenant_id",
+		BaseDomain:   "example.com",
+		Default:      "default",
+		SelectScopes: []string{"tenant:select", "admin"},
+	}
+	anonymous := &auth.Principal{Scopes: []string{"hello:read"}}
+	gateway := &auth.Principal{Subject: "gateway", Scopes: []string{"hello:read", "tenant:select"}}
+	member := &auth.Principal{Subject: "alice", Scopes: []string{"hello:read"}, Claims: map[string]string{"tenant_id": "acme"}}
+
+	cases := []struct {
+		name      string
+		principal *auth.Principal
+		host      string
+		header    string
+		status    int
+		tenant    string
+	}{
+		{name: "claim", principal: member, status: http.StatusOK, tenant: "acme"},
+		{name: "claim with matching header", principal: member, header: "acme", status: http.StatusOK, tenant: "acme"},
+		{name: "claim with other header", principal: member, header: "globex", status: http.StatusForbidden},
+		{name: "header with select scope", principal: gateway, header: "globex", status: http.StatusOK, tenant: "globex"},
+		{name: "subdomain with select scope", principal: gateway, host: "globex.example.com", status: http.StatusOK, tenant: "globex"},
+		{name: "header without select scope", principal: anonymous, header: "globex", status: http.StatusForbidden},
+		{name: "subdomain without select scope", principal: anonymous, host: "globex.example.com", status: http.StatusForbidden},
+		{name: "default tenant", principal: anonymous, status: http.StatusOK, tenant: "default"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if c.host != "" {
+				r.Host = c.host
+			}
+			if c.header != "" {
+				r.Header.Set(cfg.Header, c.header)
+			}
+			r = r.WithContext(auth.WithPrincipal(r.Context(), c.principal))
+
+			var got string
+			rec := httptest.NewRecorder()
+			Tenant(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = tenant.FromContext(r.Context())
+			})).ServeHTTP(rec, r)
+
+			if rec.Code != c.status || got != c.tenant {
+				t.Fatalf("status %d, tenant %q; want %d, %q", rec.Code, got, c.status, c.tenant)
+			}
+		})
+	}
+}
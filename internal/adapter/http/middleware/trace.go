@@ -3,58 +3,104 @@ package middleware
 import (
 	"context"
 	"net/http"
+	"strings"
 
-	"github.com/google/uuid"
+	"github.com/go-chi/chi"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+
+	platformlogger "github.com/Jexim/HelloGo/internal/platform/logger"
+	"github.com/Jexim/HelloGo/internal/platform/tracing"
 )
 
 const (
+	// TraceIDHeader carries the trace id for clients predating traceparent
 	TraceIDHeader = "X-Trace-ID"
 )
 
-type contextKey string
-
-const traceIDContextKey contextKey = "trace_id"
-
-// TraceID is a middleware that adds a trace ID to each request
+// TraceID is a middleware that runs each request in an OpenTelemetry server
+// span. The trace is continued from the W3C traceparent and tracestate
+// headers, or else from an X-Trace-ID holding a trace id, and announced on
+// the response in the same headers.
 func TraceID(logger *zap.Logger) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Get trace ID from header or generate new one
-			traceID := r.Header.Get(TraceIDHeader)
-			if traceID == "" {
-				traceID = uuid.New().String()
+			// Continue the caller's trace, if any
+			propagator := otel.GetTextMapPropagator()
+			ctx := propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			opts := []trace.SpanStartOption{
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					semconv.HTTPRequestMethodKey.String(r.Method),
+					semconv.URLPath(r.URL.Path),
+					semconv.ClientAddress(ClientIP(r)),
+					semconv.UserAgentOriginal(r.UserAgent()),
+				),
 			}
+			if !trace.SpanContextFromContext(ctx).IsValid() {
+				if traceID, ok := legacyTraceID(r.Header.Get(TraceIDHeader)); ok {
+					// The caller sent no span, so a new root span joins its trace
+					ctx = tracing.WithRequestedTraceID(ctx, traceID)
+					opts = append(opts, trace.WithNewRoot())
+				}
+			}
+
+			ctx, span := tracing.Start(ctx, r.Method, opts...)
+			defer span.End()
 
-			// Add trace ID to response headers
+			// Add trace headers to the response
+			sc := span.SpanContext()
+			traceID := sc.TraceID().String()
+			propagator.Inject(ctx, propagation.HeaderCarrier(w.Header()))
 			w.Header().Set(TraceIDHeader, traceID)
 
-			// Add trace ID to request context and logger
-			ctx := r.Context()
+			// Add trace ID to request context and logger, which later layers
+			// read with logger.FromContext
 			ctx = contextWithTraceID(ctx, traceID)
-			loggerWithTrace := logger.With(zap.String("trace_id", traceID))
+			loggerWithTrace := logger.With(zap.String("trace_id", traceID), zap.String("span_id", sc.SpanID().String()))
 			loggerWithTrace.Debug("processing request with trace ID")
+			ctx = platformlogger.WithContext(ctx, loggerWithTrace)
 
-			// Create new request with context containing trace id
+			rw := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
 			r = r.WithContext(ctx)
+			next.ServeHTTP(rw, r)
 
-			// Call next handler
-			next.ServeHTTP(w, r)
+			// The route is only known once chi routed the request
+			if rc := chi.RouteContext(r.Context()); rc != nil && rc.RoutePattern() != "" {
+				span.SetName(r.Method + " " + rc.RoutePattern())
+				span.SetAttributes(semconv.HTTPRoute(rc.RoutePattern()))
+			}
+			span.SetAttributes(semconv.HTTPResponseStatusCode(rw.status))
+			if rw.status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(rw.status))
+			}
 		})
 	}
 }
 
+// legacyTraceID parses the trace id of an X-Trace-ID header, which older
+// clients send as a 32 digit hex id or a UUID
+func legacyTraceID(header string) (trace.TraceID, bool) {
+	if header == "" {
+		return trace.TraceID{}, false
+	}
+	traceID, err := trace.TraceIDFromHex(strings.ReplaceAll(strings.ToLower(header), "-", ""))
+	return traceID, err == nil
+}
+
 // contextWithTraceID sets trace id into context
 func contextWithTraceID(ctx context.Context, traceID string) context.Context {
-	return context.WithValue(ctx, traceIDContextKey, traceID)
+	return tracing.WithTraceID(ctx, traceID)
 }
 
 // GetTraceID extracts trace id from context if present
 func GetTraceID(r *http.Request) string {
-	if v := r.Context().Value(traceIDContextKey); v != nil {
-		if s, ok := v.(string); ok {
-			return s
-		}
+	if s := tracing.TraceID(r.Context()); s != "" {
+		return s
 	}
 	// fallback to request header if present
 	if h := r.Header.Get(TraceIDHeader); h != "" {
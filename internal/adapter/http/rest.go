@@ -1,14 +1,14 @@
 package httpadapter
 
 import (
-	"database/sql"
-
 	"github.com/go-chi/chi"
 	"go.uber.org/zap"
 
 	healthrest "github.com/Jexim/HelloGo/internal/adapter/http/health"
 	"github.com/Jexim/HelloGo/internal/modules/hello"
+	platformdb "github.com/Jexim/HelloGo/internal/platform/db"
 	healthcheck "github.com/Jexim/HelloGo/internal/platform/health"
+	"github.com/Jexim/HelloGo/internal/platform/metrics"
 )
 
 type REST struct {
@@ -20,9 +20,12 @@ type REST struct {
 }
 
 type InitArgs struct {
-	Logger *zap.Logger
-	DB     *sql.DB
-	Router chi.Router
+	Logger  *zap.Logger
+	Metrics *metrics.Metrics
+	DBs     *platformdb.Registry
+	Router  chi.Router
+	// OpsRouter serves health and readiness probes; Router when nil
+	OpsRouter chi.Router
 }
 
 type ArgsREST struct {
@@ -31,12 +34,20 @@ type ArgsREST struct {
 
 func New(args InitArgs, argsREST ArgsREST) (*REST, error) {
 	// Initialize health checker
-	healthChecker := healthcheck.NewChecker(args.DB, args.Logger)
+	healthChecker := healthcheck.NewChecker(args.DBs, args.Metrics, args.Logger)
+
+	ops := args.OpsRouter
+	if ops == nil {
+		ops = args.Router
+	}
+	health := healthrest.New(ops, "/health", healthChecker, args.Logger)
+	ops.Get("/livez", health.Live())
+	ops.Get("/readyz", health.Ready())
 
 	return &REST{
 		logger: args.Logger,
 		Hello:  argsREST.Hello,
-		Health: healthrest.New(args.Router, "/health", healthChecker, args.Logger),
+		Health: health,
 		router: args.Router,
 	}, nil
 }
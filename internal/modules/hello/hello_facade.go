@@ -1,14 +1,26 @@
 package hello
 
 import (
-	"database/sql"
-
 	"github.com/go-chi/chi"
 
+	httpmw "github.com/Jexim/HelloGo/internal/adapter/http/middleware"
+	"github.com/Jexim/HelloGo/internal/modules/hello/migrations"
 	"github.com/Jexim/HelloGo/internal/modules/hello/model"
+	"github.com/Jexim/HelloGo/internal/modules/hello/policy"
+	"github.com/Jexim/HelloGo/internal/modules/hello/repo/cached"
 	"github.com/Jexim/HelloGo/internal/modules/hello/repo/datastore"
+	"github.com/Jexim/HelloGo/internal/modules/hello/repo/memory"
+	"github.com/Jexim/HelloGo/internal/modules/hello/repo/sqlc/schema"
+	"github.com/Jexim/HelloGo/internal/modules/hello/repo/sqlite"
+	sqlitemigrations "github.com/Jexim/HelloGo/internal/modules/hello/repo/sqlite/migrations"
 	"github.com/Jexim/HelloGo/internal/modules/hello/rest"
 	"github.com/Jexim/HelloGo/internal/modules/hello/usecase"
+	"github.com/Jexim/HelloGo/internal/platform/auth"
+	"github.com/Jexim/HelloGo/internal/platform/cache"
+	"github.com/Jexim/HelloGo/internal/platform/config"
+	platformdb "github.com/Jexim/HelloGo/internal/platform/db"
+	"github.com/Jexim/HelloGo/internal/platform/metrics"
+	"github.com/Jexim/HelloGo/internal/platform/migrate"
 )
 
 type (
@@ -18,19 +30,69 @@ type (
 
 	Usecase = model.Usecase
 
+	Policy = model.Policy
+
 	RESTHello = model.REST
 )
 
-func NewDatastore(db *sql.DB) Datastore {
-	return datastore.NewDatastore(db)
+// Module is the name hello is registered under for database routing
+const Module = "hello"
+
+// Migrations returns the hello schema migrations. Hello data is tenant scoped,
+// so they also go to every database a tenant is routed to.
+func Migrations() migrate.Set {
+	return migrate.Set{
+		Module:       Module,
+		Database:     platformdb.DefaultName,
+		TenantScoped: true,
+		FS:           migrations.FS,
+		SQLiteFS:     sqlitemigrations.FS,
+		Schema:       schema.FS,
+	}
+}
+
+// DatastoreOptions selects and configures the hello datastore
+type DatastoreOptions struct {
+	// Driver is the database driver; platformdb.DriverMemory keeps hellos in process
+	Driver string
+	// Source hands out the SQL databases for every other driver
+	Source platformdb.Source
+	// Cache, when enabled, reads hellos through a cache
+	Cache config.CacheConfig
+	// Metrics records the hits and misses of the cache
+	Metrics *metrics.Metrics
+}
+
+func NewDatastore(opts DatastoreOptions) (Datastore, error) {
+	var ds Datastore
+	switch opts.Driver {
+	case platformdb.DriverMemory:
+		ds = memory.NewDatastore()
+	case platformdb.DriverSQLite:
+		ds = sqlite.NewDatastore(opts.Source)
+	default:
+		ds = datastore.NewDatastore(opts.Source)
+	}
+	if !opts.Cache.Enabled {
+		return ds, nil
+	}
+	store, err := cache.New(cached.Name, opts.Cache, opts.Metrics)
+	if err != nil {
+		return nil, err
+	}
+	return cached.NewDatastore(ds, store, cache.TTL(opts.Cache), opts.Metrics), nil
+}
+
+func NewPolicy(az *auth.Authorizer) Policy {
+	return policy.New(az)
 }
 
-func NewUsecase(ds Datastore) Usecase {
-	return usecase.New(ds)
+func NewUsecase(ds Datastore, p Policy) Usecase {
+	return usecase.New(ds, p)
 }
 
-func NewREST(mux *chi.Mux, prefix string, helloUC Usecase) RESTHello {
-	return rest.New(mux, prefix, helloUC)
+func NewREST(mux *chi.Mux, prefix string, helloUC Usecase, az *auth.Authorizer, rc *httpmw.ResponseCache) RESTHello {
+	return rest.New(mux, prefix, helloUC, az, rc)
 }
 
 var (
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE hello ADD COLUMN IF NOT EXISTS author TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE hello DROP COLUMN IF EXISTS author;
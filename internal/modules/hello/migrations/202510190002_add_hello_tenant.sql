@@ -0,0 +1,21 @@
+-- +goose Up
+-- Existing rows are backfilled into the "default" tenant; new rows take the tenant of the transaction.
+ALTER TABLE hello ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE hello ALTER COLUMN tenant_id SET DEFAULT current_setting('app.tenant_id');
+CREATE INDEX IF NOT EXISTS hello_tenant_id_idx ON hello (tenant_id, id);
+
+-- FORCE applies the policy to the table owner as well. Superusers and BYPASSRLS roles
+-- still skip it, so the service must not connect as one.
+ALTER TABLE hello ENABLE ROW LEVEL SECURITY;
+ALTER TABLE hello FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS hello_tenant_isolation ON hello;
+CREATE POLICY hello_tenant_isolation ON hello
+  USING (tenant_id = current_setting('app.tenant_id', true))
+  WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+-- +goose Down
+DROP POLICY IF EXISTS hello_tenant_isolation ON hello;
+ALTER TABLE hello NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE hello DISABLE ROW LEVEL SECURITY;
+DROP INDEX IF EXISTS hello_tenant_id_idx;
+ALTER TABLE hello DROP COLUMN IF EXISTS tenant_id;
@@ -2,14 +2,25 @@ package model
 
 import (
 	"context"
-	"errors"
+	"fmt"
 	"net/http"
+
+	"github.com/Jexim/HelloGo/internal/platform/apperr"
 )
 
 var (
-	ErrNotFound = errors.New("hello not found")
+	ErrNotFound = fmt.Errorf("hello %w", apperr.ErrNotFound)
+)
+
+const (
+	ScopeRead  = "hello:read"
+	ScopeWrite = "hello:write"
+	ScopeAdmin = "hello:admin"
 )
 
+// SurrogateKey tags every cached hello response; purging it drops them all
+const SurrogateKey = "hello"
+
 //go:generate go run -mod=mod go.uber.org/mock/mockgen -mock_names Datastore=MockedDatastore -package mock -destination ../mock/hello_datastore_mock.go . Datastore
 type Datastore interface {
 	Create(ctx context.Context, hello *Hello) (*Hello, error)
@@ -24,8 +35,22 @@ type Usecase interface {
 	Datastore
 }
 
+//go:generate go run -mod=mod go.uber.org/mock/mockgen -mock_names Policy=MockedPolicy -package mock -destination ../mock/hello_policy_mock.go . Policy
+type Policy interface {
+	CanRead(ctx context.Context) error
+	CanCreate(ctx context.Context) error
+	// CanUpdate and CanDelete with a nil hello check whether the caller may
+	// change any hello at all, before it is loaded
+	CanUpdate(ctx context.Context, hello *Hello) error
+	CanDelete(ctx context.Context, hello *Hello) error
+}
+
 type REST interface {
 	ListHellos(w http.ResponseWriter, r *http.Request)
+	GetHello(w http.ResponseWriter, r *http.Request)
+	CreateHello(w http.ResponseWriter, r *http.Request)
+	UpdateHello(w http.ResponseWriter, r *http.Request)
+	DeleteHello(w http.ResponseWriter, r *http.Request)
 }
 
 // Hello represents
@@ -33,4 +58,5 @@ type REST interface {
 type Hello struct {
 	ID      uint   `json:"id"`
 	Message string `json:"message"`
+	Author  string `json:"author"`
 }
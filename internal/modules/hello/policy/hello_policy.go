@@ -0,0 +1,55 @@
+package policy
+
+import (
+	"context"
+
+	"github.com/Jexim/HelloGo/internal/modules/hello/model"
+	"github.com/Jexim/HelloGo/internal/platform/auth"
+)
+
+type helloPolicy struct {
+	az *auth.Authorizer
+}
+
+// New returns the scope and ownership based policy for hellos:
+// readers need any hello scope, writers need hello:write, and only
+// the author or a hello:admin may update or delete a message.
+func New(az *auth.Authorizer) model.Policy {
+	return &helloPolicy{az: az}
+}
+
+func (p *helloPolicy) CanRead(ctx context.Context) error {
+	return p.az.RequireScope(ctx, "hello.read", model.ScopeRead, model.ScopeWrite, model.ScopeAdmin)
+}
+
+func (p *helloPolicy) CanCreate(ctx context.Context) error {
+	return p.az.RequireScope(ctx, "hello.create", model.ScopeWrite, model.ScopeAdmin)
+}
+
+func (p *helloPolicy) CanUpdate(ctx context.Context, h *model.Hello) error {
+	return p.modify(ctx, "hello.update", h)
+}
+
+func (p *helloPolicy) CanDelete(ctx context.Context, h *model.Hello) error {
+	return p.modify(ctx, "hello.delete", h)
+}
+
+// modify allows admins on any message and writers on their own messages.
+// A nil hello only checks the scope, before the hello is loaded, so callers
+// who may change no hello cannot tell which ids exist; the grant itself is
+// decided and logged once the author is known.
+func (p *helloPolicy) modify(ctx context.Context, action string, h *model.Hello) error {
+	principal := auth.FromContext(ctx)
+	switch {
+	case h == nil && principal.HasScope(model.ScopeWrite, model.ScopeAdmin):
+		return nil
+	case principal.HasScope(model.ScopeAdmin):
+		return p.az.Decide(ctx, action, true, "admin")
+	case !principal.HasScope(model.ScopeWrite):
+		return p.az.Decide(ctx, action, false, "missing scope")
+	case principal.Anonymous() || h.Author != principal.Subject:
+		return p.az.Decide(ctx, action, false, "not the author")
+	default:
+		return p.az.Decide(ctx, action, true, "author")
+	}
+}
@@ -0,0 +1,170 @@
+// Package cached is a read-through cache in front of another hello datastore.
+//
+// Entries are keyed by tenant and a per-tenant generation that every write
+// replaces, so entries cached before a write, including those of loads still in
+// flight, are never read again and expire on their own. With the in-process
+// backend other instances of the service may serve stale hellos for up to the TTL.
+package cached
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"github.com/Jexim/HelloGo/internal/modules/hello/model"
+	"github.com/Jexim/HelloGo/internal/platform/cache"
+	"github.com/Jexim/HelloGo/internal/platform/metrics"
+	"github.com/Jexim/HelloGo/internal/platform/tenant"
+)
+
+// Name labels the cache metrics of the hello datastore
+const Name = "hello"
+
+type helloDatastore struct {
+	next    model.Datastore
+	store   cache.Store
+	ttl     time.Duration
+	metrics *metrics.Metrics
+	group   singleflight.Group
+}
+
+func NewDatastore(next model.Datastore, store cache.Store, ttl time.Duration, m *metrics.Metrics) model.Datastore {
+	return &helloDatastore{next: next, store: store, ttl: ttl, metrics: m}
+}
+
+// Create stores the hello and invalidates the tenant's cached entries
+func (d *helloDatastore) Create(ctx context.Context, in *model.Hello) (*model.Hello, error) {
+	out, err := d.next.Create(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	d.invalidate(ctx)
+	return out, nil
+}
+
+// GetAll returns a page of hellos from the cache or the next datastore
+func (d *helloDatastore) GetAll(ctx context.Context, limit, offset int) ([]model.Hello, error) {
+	prefix, err := d.prefix(ctx)
+	if err != nil {
+		return d.next.GetAll(ctx, limit, offset)
+	}
+	key := fmt.Sprintf("%s:list:%d:%d", prefix, limit, offset)
+	var out []model.Hello
+	err = d.load(ctx, key, &out, func(ctx context.Context) (any, error) {
+		return d.next.GetAll(ctx, limit, offset)
+	})
+	return out, err
+}
+
+// Get returns a hello from the cache or the next datastore
+func (d *helloDatastore) Get(ctx context.Context, id int) (*model.Hello, error) {
+	prefix, err := d.prefix(ctx)
+	if err != nil {
+		return d.next.Get(ctx, id)
+	}
+	var out model.Hello
+	err = d.load(ctx, fmt.Sprintf("%s:item:%d", prefix, id), &out, func(ctx context.Context) (any, error) {
+		return d.next.Get(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Update changes the hello and invalidates the tenant's cached entries
+func (d *helloDatastore) Update(ctx context.Context, id int, in *model.Hello) error {
+	if err := d.next.Update(ctx, id, in); err != nil {
+		return err
+	}
+	d.invalidate(ctx)
+	return nil
+}
+
+// Delete removes the hello and invalidates the tenant's cached entries
+func (d *helloDatastore) Delete(ctx context.Context, id int) error {
+	if err := d.next.Delete(ctx, id); err != nil {
+		return err
+	}
+	d.invalidate(ctx)
+	return nil
+}
+
+// load decodes the cached value of key into out, or calls fetch once for all
+// concurrent misses of key and caches its result. The shared fetch outlives the
+// context of the caller that started it, so one cancelled request does not fail
+// the others waiting on it.
+func (d *helloDatastore) load(ctx context.Context, key string, out any, fetch func(ctx context.Context) (any, error)) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if b, ok, err := d.store.Get(ctx, key); err == nil && ok {
+		if json.Unmarshal(b, out) == nil {
+			d.metrics.CacheHits.WithLabelValues(Name).Inc()
+			return nil
+		}
+	}
+	d.metrics.CacheMisses.WithLabelValues(Name).Inc()
+
+	ch := d.group.DoChan(key, func() (any, error) {
+		ctx := context.WithoutCancel(ctx)
+		v, err := fetch(ctx)
+		if err != nil {
+			return nil, err
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		_ = d.store.Set(ctx, key, b, d.ttl)
+		return b, nil
+	})
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return res.Err
+		}
+		return json.Unmarshal(res.Val.([]byte), out)
+	}
+}
+
+// prefix returns the key prefix of the tenant in ctx and its current
+// generation, starting a generation if there is none
+func (d *helloDatastore) prefix(ctx context.Context) (string, error) {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return "", err
+	}
+	key := generationKey(tenantID)
+	b, ok, err := d.store.Get(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	gen := string(b)
+	if !ok {
+		gen = strconv.FormatInt(time.Now().UnixNano(), 36)
+		if err := d.store.Set(ctx, key, []byte(gen), d.ttl); err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("%s:%s:%s", Name, tenantID, gen), nil
+}
+
+// invalidate drops the generation of the tenant in ctx
+func (d *helloDatastore) invalidate(ctx context.Context) {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return
+	}
+	_ = d.store.Delete(ctx, generationKey(tenantID))
+}
+
+func generationKey(tenantID string) string {
+	return fmt.Sprintf("%s:%s:gen", Name, tenantID)
+}
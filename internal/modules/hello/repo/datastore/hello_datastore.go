@@ -3,57 +3,125 @@ package datastore
 import (
 	"context"
 	"database/sql"
+	"errors"
+
+	"go.uber.org/zap"
 
 	"github.com/Jexim/HelloGo/internal/modules/hello/model"
 	gen "github.com/Jexim/HelloGo/internal/modules/hello/repo/sqlc/gen"
+	platformdb "github.com/Jexim/HelloGo/internal/platform/db"
+	"github.com/Jexim/HelloGo/internal/platform/logger"
 )
 
 type helloDatastore struct {
-	db *sql.DB
-	q  *gen.Queries
+	src platformdb.Source
 }
 
-func NewDatastore(db *sql.DB) model.Datastore {
-	return &helloDatastore{db: db, q: gen.New(db)}
+func NewDatastore(src platformdb.Source) model.Datastore {
+	return &helloDatastore{src: src}
 }
 
 // Create creates a new hello in the database
 func (d *helloDatastore) Create(ctx context.Context, in *model.Hello) (*model.Hello, error) {
-	h, err := d.q.CreateHello(ctx, in.Message)
-	if err != nil {
-		return nil, err
-	}
-	return &model.Hello{ID: uint(h.ID), Message: h.Message}, nil
+	var out *model.Hello
+	err := d.write(ctx, func(q *gen.Queries) error {
+		h, err := q.CreateHello(ctx, gen.CreateHelloParams{Message: in.Message, Author: in.Author})
+		if err != nil {
+			return err
+		}
+		out = toModel(h)
+		return nil
+	})
+	return out, err
 }
 
 // GetAll retrieves all hellos from the database
 func (d *helloDatastore) GetAll(ctx context.Context, limit, offset int) ([]model.Hello, error) {
-	list, err := d.q.ListHellos(ctx, gen.ListHellosParams{Limit: int32(limit), Offset: int32(offset)})
-	if err != nil {
-		return nil, err
-	}
-	result := make([]model.Hello, 0, len(list))
-	for _, it := range list {
-		result = append(result, model.Hello{ID: uint(it.ID), Message: it.Message})
-	}
-	return result, nil
+	var result []model.Hello
+	err := d.read(ctx, func(q *gen.Queries) error {
+		list, err := q.ListHellos(ctx, gen.ListHellosParams{Limit: int32(limit), Offset: int32(offset)})
+		if err != nil {
+			return err
+		}
+		result = make([]model.Hello, 0, len(list))
+		for _, it := range list {
+			result = append(result, *toModel(it))
+		}
+		return nil
+	})
+	return result, err
 }
 
 // Get retrieves a hello by ID from the database
 func (d *helloDatastore) Get(ctx context.Context, id int) (*model.Hello, error) {
-	h, err := d.q.GetHello(ctx, int32(id))
-	if err != nil {
-		return nil, err
-	}
-	return &model.Hello{ID: uint(h.ID), Message: h.Message}, nil
+	var out *model.Hello
+	err := d.read(ctx, func(q *gen.Queries) error {
+		h, err := q.GetHello(ctx, int32(id))
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		out = toModel(h)
+		return nil
+	})
+	return out, err
 }
 
 // Update updates a hello in the database
 func (d *helloDatastore) Update(ctx context.Context, id int, in *model.Hello) error {
-	return d.q.UpdateHello(ctx, gen.UpdateHelloParams{Message: in.Message, ID: int32(id)})
+	return d.write(ctx, func(q *gen.Queries) error {
+		n, err := q.UpdateHello(ctx, gen.UpdateHelloParams{Message: in.Message, ID: int32(id)})
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return model.ErrNotFound
+		}
+		return nil
+	})
 }
 
 // Delete removes a hello from the database
 func (d *helloDatastore) Delete(ctx context.Context, id int) error {
-	return d.q.DeleteHello(ctx, int32(id))
+	return d.write(ctx, func(q *gen.Queries) error {
+		n, err := q.DeleteHello(ctx, int32(id))
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return model.ErrNotFound
+		}
+		return nil
+	})
+}
+
+// read runs fn against a replica of the tenant's database
+func (d *helloDatastore) read(ctx context.Context, fn func(q *gen.Queries) error) error {
+	err := d.src.ReadTx(ctx, func(db platformdb.DBTX) error {
+		return fn(gen.New(db))
+	})
+	logFailure(ctx, "read", err)
+	return err
+}
+
+// write runs fn against the primary of the tenant's database
+func (d *helloDatastore) write(ctx context.Context, fn func(q *gen.Queries) error) error {
+	err := d.src.WriteTx(ctx, func(db platformdb.DBTX) error {
+		return fn(gen.New(db))
+	})
+	logFailure(ctx, "write", err)
+	return err
+}
+
+// logFailure logs errors other than a missing hello with the request's logger
+func logFailure(ctx context.Context, op string, err error) {
+	if err != nil && !errors.Is(err, model.ErrNotFound) {
+		logger.FromContext(ctx).Warn("hello datastore "+op+" failed", zap.Error(err))
+	}
+}
+
+func toModel(h gen.Hello) *model.Hello {
+	return &model.Hello{ID: uint(h.ID), Message: h.Message, Author: h.Author}
 }
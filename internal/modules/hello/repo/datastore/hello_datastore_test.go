@@ -0,0 +1,15 @@
+package datastore_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/Jexim/HelloGo/internal/modules/hello/repo/datastoretest"
+)
+
+func TestConformance(t *testing.T) {
+	if os.Getenv("TEST_DATABASE_URL") == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	datastoretest.Run(t, datastoretest.Postgres)
+}
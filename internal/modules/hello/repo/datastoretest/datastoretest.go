@@ -0,0 +1,330 @@
+// Package datastoretest is a conformance suite every model.Datastore implementation must pass.
+//
+// Implementations run it from their tests:
+//
+//	func TestConformance(t *testing.T) {
+//		datastoretest.Run(t, datastoretest.Memory)
+//		datastoretest.Run(t, datastoretest.SQLite)
+//	}
+package datastoretest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/Jexim/HelloGo/internal/modules/hello/migrations"
+	"github.com/Jexim/HelloGo/internal/modules/hello/model"
+	"github.com/Jexim/HelloGo/internal/modules/hello/repo/cached"
+	"github.com/Jexim/HelloGo/internal/modules/hello/repo/datastore"
+	"github.com/Jexim/HelloGo/internal/modules/hello/repo/memory"
+	"github.com/Jexim/HelloGo/internal/modules/hello/repo/sqlite"
+	sqlitemigrations "github.com/Jexim/HelloGo/internal/modules/hello/repo/sqlite/migrations"
+	"github.com/Jexim/HelloGo/internal/platform/cache"
+	"github.com/Jexim/HelloGo/internal/platform/config"
+	platformdb "github.com/Jexim/HelloGo/internal/platform/db"
+	"github.com/Jexim/HelloGo/internal/platform/metrics"
+	"github.com/Jexim/HelloGo/internal/platform/migrate"
+	"github.com/Jexim/HelloGo/internal/platform/tenant"
+)
+
+// Factory returns a datastore for one subtest. Stores may share data between
+// calls; the suite isolates subtests by giving each its own tenant.
+type Factory func(t *testing.T) model.Datastore
+
+// Run exercises CRUD, pagination, not-found semantics, tenant isolation,
+// concurrent writes and context cancellation against the datastore from factory
+func Run(t *testing.T, factory Factory) {
+	t.Run("CreateAndGet", func(t *testing.T) { testCreateAndGet(t, factory(t)) })
+	t.Run("IncreasingIDs", func(t *testing.T) { testIncreasingIDs(t, factory(t)) })
+	t.Run("Pagination", func(t *testing.T) { testPagination(t, factory(t)) })
+	t.Run("Update", func(t *testing.T) { testUpdate(t, factory(t)) })
+	t.Run("Delete", func(t *testing.T) { testDelete(t, factory(t)) })
+	t.Run("NotFound", func(t *testing.T) { testNotFound(t, factory(t)) })
+	t.Run("TenantIsolation", func(t *testing.T) { testTenantIsolation(t, factory(t)) })
+	t.Run("MissingTenant", func(t *testing.T) { testMissingTenant(t, factory(t)) })
+	t.Run("ConcurrentWrites", func(t *testing.T) { testConcurrentWrites(t, factory(t)) })
+	t.Run("ContextCancellation", func(t *testing.T) { testContextCancellation(t, factory(t)) })
+}
+
+// Memory is the Factory of the in-memory datastore
+func Memory(t *testing.T) model.Datastore {
+	return memory.NewDatastore()
+}
+
+// Cached is the Factory of the in-memory datastore behind a read-through cache
+func Cached(t *testing.T) model.Datastore {
+	m := newMetrics()
+	return cached.NewDatastore(memory.NewDatastore(), cache.NewLRU(cached.Name, 64, m), time.Minute, m)
+}
+
+// Postgres is the Factory of the Postgres datastore. It migrates the database at
+// TEST_DATABASE_URL and skips the test when the variable is unset. The role must
+// not be a superuser, or Row-Level Security and with it TenantIsolation are bypassed.
+func Postgres(t *testing.T) model.Datastore {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	src := openSource(t, config.DatabaseConfig{Driver: platformdb.DriverPgx, URI: dsn})
+	return datastore.NewDatastore(src)
+}
+
+// SQLite is the Factory of the SQLite datastore, backed by a fresh database file
+// in the test's temporary directory
+func SQLite(t *testing.T) model.Datastore {
+	uri := filepath.Join(t.TempDir(), "hello.db")
+	src := openSource(t, config.DatabaseConfig{Driver: platformdb.DriverSQLite, URI: uri})
+	return sqlite.NewDatastore(src)
+}
+
+// openSource opens dc as the main database, applies the hello migrations and
+// returns the source of the hello module
+func openSource(t *testing.T, dc config.DatabaseConfig) platformdb.Source {
+	ctx := context.Background()
+	log := zap.NewNop()
+	reg, err := platformdb.OpenAll(ctx, map[string]config.DatabaseConfig{platformdb.DefaultName: dc}, log)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { _ = reg.Close() })
+
+	set := migrate.Set{Module: "hello", FS: migrations.FS, SQLiteFS: sqlitemigrations.FS}
+	m, err := migrate.New(reg.Get(platformdb.DefaultName), dc.Driver, set, log)
+	if err != nil {
+		t.Fatalf("create migrator: %v", err)
+	}
+	if err := m.Up(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	resolver, err := platformdb.NewResolver(reg, platformdb.Routing{}, platformdb.NewInstrumenter(newMetrics(), log, 0))
+	if err != nil {
+		t.Fatalf("create resolver: %v", err)
+	}
+	return resolver.For("hello")
+}
+
+// newMetrics returns metrics on a registry of their own
+func newMetrics() *metrics.Metrics {
+	return metrics.New(prometheus.NewRegistry(), config.MetricsConfig{})
+}
+
+// tenantContext returns a context scoped to a tenant no other test uses
+func tenantContext(t *testing.T) context.Context {
+	return tenant.WithTenant(context.Background(), fmt.Sprintf("%s-%d", t.Name(), time.Now().UnixNano()))
+}
+
+func create(t *testing.T, ctx context.Context, ds model.Datastore, msg string) *model.Hello {
+	t.Helper()
+	h, err := ds.Create(ctx, &model.Hello{Message: msg, Author: "tester"})
+	if err != nil {
+		t.Fatalf("Create(%q): %v", msg, err)
+	}
+	return h
+}
+
+func testCreateAndGet(t *testing.T, ds model.Datastore) {
+	ctx := tenantContext(t)
+	created := create(t, ctx, ds, "hello")
+	if created.ID == 0 || created.Message != "hello" || created.Author != "tester" {
+		t.Fatalf("Create returned %+v", created)
+	}
+	got, err := ds.Get(ctx, int(created.ID))
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if *got != *created {
+		t.Fatalf("Get returned %+v, want %+v", got, created)
+	}
+}
+
+func testIncreasingIDs(t *testing.T, ds model.Datastore) {
+	ctx := tenantContext(t)
+	first := create(t, ctx, ds, "first")
+	second := create(t, ctx, ds, "second")
+	if second.ID <= first.ID {
+		t.Fatalf("ids not increasing: %d then %d", first.ID, second.ID)
+	}
+}
+
+func testPagination(t *testing.T, ds model.Datastore) {
+	ctx := tenantContext(t)
+	var ids []uint
+	for i := 0; i < 5; i++ {
+		ids = append(ids, create(t, ctx, ds, fmt.Sprintf("hello %d", i)).ID)
+	}
+
+	cases := []struct {
+		limit, offset int
+		want          []uint
+	}{
+		{limit: 10, offset: 0, want: ids},
+		{limit: 2, offset: 0, want: ids[:2]},
+		{limit: 2, offset: 2, want: ids[2:4]},
+		{limit: 2, offset: 4, want: ids[4:]},
+		{limit: 2, offset: 5, want: nil},
+		{limit: 2, offset: 100, want: nil},
+		{limit: 0, offset: 0, want: nil},
+	}
+	for _, c := range cases {
+		got, err := ds.GetAll(ctx, c.limit, c.offset)
+		if err != nil {
+			t.Fatalf("GetAll(%d, %d): %v", c.limit, c.offset, err)
+		}
+		if got == nil {
+			t.Fatalf("GetAll(%d, %d) returned nil, want empty slice", c.limit, c.offset)
+		}
+		if len(got) != len(c.want) {
+			t.Fatalf("GetAll(%d, %d) returned %d items, want %d", c.limit, c.offset, len(got), len(c.want))
+		}
+		for i := range got {
+			if got[i].ID != c.want[i] {
+				t.Fatalf("GetAll(%d, %d)[%d].ID = %d, want %d", c.limit, c.offset, i, got[i].ID, c.want[i])
+			}
+		}
+	}
+}
+
+func testUpdate(t *testing.T, ds model.Datastore) {
+	ctx := tenantContext(t)
+	h := create(t, ctx, ds, "before")
+	if err := ds.Update(ctx, int(h.ID), &model.Hello{Message: "after", Author: "someone else"}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	got, err := ds.Get(ctx, int(h.ID))
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Message != "after" {
+		t.Fatalf("message = %q, want %q", got.Message, "after")
+	}
+	if got.Author != "tester" {
+		t.Fatalf("Update changed author to %q", got.Author)
+	}
+}
+
+func testDelete(t *testing.T, ds model.Datastore) {
+	ctx := tenantContext(t)
+	h := create(t, ctx, ds, "doomed")
+	if err := ds.Delete(ctx, int(h.ID)); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := ds.Get(ctx, int(h.ID)); !errors.Is(err, model.ErrNotFound) {
+		t.Fatalf("Get after Delete: %v, want ErrNotFound", err)
+	}
+	if err := ds.Delete(ctx, int(h.ID)); !errors.Is(err, model.ErrNotFound) {
+		t.Fatalf("second Delete: %v, want ErrNotFound", err)
+	}
+}
+
+func testNotFound(t *testing.T, ds model.Datastore) {
+	ctx := tenantContext(t)
+	const missing = 1 << 30
+	if _, err := ds.Get(ctx, missing); !errors.Is(err, model.ErrNotFound) {
+		t.Fatalf("Get: %v, want ErrNotFound", err)
+	}
+	if err := ds.Update(ctx, missing, &model.Hello{Message: "x"}); !errors.Is(err, model.ErrNotFound) {
+		t.Fatalf("Update: %v, want ErrNotFound", err)
+	}
+	if err := ds.Delete(ctx, missing); !errors.Is(err, model.ErrNotFound) {
+		t.Fatalf("Delete: %v, want ErrNotFound", err)
+	}
+}
+
+func testTenantIsolation(t *testing.T, ds model.Datastore) {
+	owner := tenantContext(t)
+	other := tenant.WithTenant(context.Background(), tenant.FromContext(owner)+"-other")
+	h := create(t, owner, ds, "private")
+
+	if _, err := ds.Get(other, int(h.ID)); !errors.Is(err, model.ErrNotFound) {
+		t.Fatalf("Get from other tenant: %v, want ErrNotFound", err)
+	}
+	if err := ds.Update(other, int(h.ID), &model.Hello{Message: "stolen"}); !errors.Is(err, model.ErrNotFound) {
+		t.Fatalf("Update from other tenant: %v, want ErrNotFound", err)
+	}
+	if err := ds.Delete(other, int(h.ID)); !errors.Is(err, model.ErrNotFound) {
+		t.Fatalf("Delete from other tenant: %v, want ErrNotFound", err)
+	}
+	list, err := ds.GetAll(other, 100, 0)
+	if err != nil {
+		t.Fatalf("GetAll from other tenant: %v", err)
+	}
+	if len(list) != 0 {
+		t.Fatalf("other tenant sees %d hellos", len(list))
+	}
+	if got, err := ds.Get(owner, int(h.ID)); err != nil || got.Message != "private" {
+		t.Fatalf("owner Get: %+v, %v", got, err)
+	}
+}
+
+func testMissingTenant(t *testing.T, ds model.Datastore) {
+	ctx := context.Background()
+	if _, err := ds.Create(ctx, &model.Hello{Message: "x"}); !errors.Is(err, tenant.ErrMissing) {
+		t.Fatalf("Create: %v, want tenant.ErrMissing", err)
+	}
+	if _, err := ds.GetAll(ctx, 10, 0); !errors.Is(err, tenant.ErrMissing) {
+		t.Fatalf("GetAll: %v, want tenant.ErrMissing", err)
+	}
+}
+
+func testConcurrentWrites(t *testing.T, ds model.Datastore) {
+	ctx := tenantContext(t)
+	const n = 20
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		ids  = make(map[uint]bool, n)
+		errs []error
+	)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			h, err := ds.Create(ctx, &model.Hello{Message: fmt.Sprintf("concurrent %d", i)})
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, err)
+				return
+			}
+			ids[h.ID] = true
+		}(i)
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		t.Fatalf("concurrent Create: %v", errs[0])
+	}
+	if len(ids) != n {
+		t.Fatalf("got %d distinct ids, want %d", len(ids), n)
+	}
+	list, err := ds.GetAll(ctx, 2*n, 0)
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	if len(list) != n {
+		t.Fatalf("GetAll returned %d hellos, want %d", len(list), n)
+	}
+}
+
+func testContextCancellation(t *testing.T, ds model.Datastore) {
+	ctx, cancel := context.WithCancel(tenantContext(t))
+	cancel()
+	if _, err := ds.Create(ctx, &model.Hello{Message: "x"}); err == nil {
+		t.Fatal("Create with cancelled context succeeded")
+	}
+	if _, err := ds.GetAll(ctx, 10, 0); err == nil {
+		t.Fatal("GetAll with cancelled context succeeded")
+	}
+	if _, err := ds.Get(ctx, 1); err == nil {
+		t.Fatal("Get with cancelled context succeeded")
+	}
+}
@@ -0,0 +1,128 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/Jexim/HelloGo/internal/modules/hello/model"
+	"github.com/Jexim/HelloGo/internal/platform/tenant"
+)
+
+type row struct {
+	hello    model.Hello
+	tenantID string
+}
+
+// helloDatastore keeps hellos in memory with the semantics of the Postgres datastore:
+// ids come from one sequence shared by all tenants, lists are ordered by id and
+// every operation only sees rows of the tenant in the context.
+type helloDatastore struct {
+	mu     sync.RWMutex
+	nextID uint
+	rows   map[uint]row
+}
+
+func NewDatastore() model.Datastore {
+	return &helloDatastore{rows: make(map[uint]row)}
+}
+
+// Create stores a new hello for the tenant in ctx
+func (d *helloDatastore) Create(ctx context.Context, in *model.Hello) (*model.Hello, error) {
+	tenantID, err := d.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.nextID++
+	h := model.Hello{ID: d.nextID, Message: in.Message, Author: in.Author}
+	d.rows[h.ID] = row{hello: h, tenantID: tenantID}
+	return &h, nil
+}
+
+// GetAll returns a page of the tenant's hellos ordered by id
+func (d *helloDatastore) GetAll(ctx context.Context, limit, offset int) ([]model.Hello, error) {
+	tenantID, err := d.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	d.mu.RLock()
+	result := make([]model.Hello, 0)
+	for _, r := range d.rows {
+		if r.tenantID == tenantID {
+			result = append(result, r.hello)
+		}
+	}
+	d.mu.RUnlock()
+
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= len(result) {
+		return []model.Hello{}, nil
+	}
+	result = result[offset:]
+	if limit >= 0 && limit < len(result) {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// Get returns the tenant's hello with id
+func (d *helloDatastore) Get(ctx context.Context, id int) (*model.Hello, error) {
+	tenantID, err := d.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	r, ok := d.rows[uint(id)]
+	if !ok || id <= 0 || r.tenantID != tenantID {
+		return nil, model.ErrNotFound
+	}
+	h := r.hello
+	return &h, nil
+}
+
+// Update changes the message of the tenant's hello with id
+func (d *helloDatastore) Update(ctx context.Context, id int, in *model.Hello) error {
+	tenantID, err := d.begin(ctx)
+	if err != nil {
+		return err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	r, ok := d.rows[uint(id)]
+	if !ok || id <= 0 || r.tenantID != tenantID {
+		return model.ErrNotFound
+	}
+	r.hello.Message = in.Message
+	d.rows[uint(id)] = r
+	return nil
+}
+
+// Delete removes the tenant's hello with id
+func (d *helloDatastore) Delete(ctx context.Context, id int) error {
+	tenantID, err := d.begin(ctx)
+	if err != nil {
+		return err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	r, ok := d.rows[uint(id)]
+	if !ok || id <= 0 || r.tenantID != tenantID {
+		return model.ErrNotFound
+	}
+	delete(d.rows, uint(id))
+	return nil
+}
+
+// begin fails like the database would on a cancelled context or a missing tenant
+func (d *helloDatastore) begin(ctx context.Context) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return tenant.Require(ctx)
+}
@@ -0,0 +1,11 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/Jexim/HelloGo/internal/modules/hello/repo/datastoretest"
+)
+
+func TestConformance(t *testing.T) {
+	datastoretest.Run(t, datastoretest.Memory)
+}
@@ -10,30 +10,43 @@ import (
 )
 
 const createHello = `-- name: CreateHello :one
-INSERT INTO hello (message)
-VALUES ($1)
-RETURNING id, message
+INSERT INTO hello (message, author)
+VALUES ($1, $2)
+RETURNING id, message, author, tenant_id
 `
 
-func (q *Queries) CreateHello(ctx context.Context, message string) (Hello, error) {
-	row := q.db.QueryRowContext(ctx, createHello, message)
+type CreateHelloParams struct {
+	Message string `json:"message"`
+	Author  string `json:"author"`
+}
+
+func (q *Queries) CreateHello(ctx context.Context, arg CreateHelloParams) (Hello, error) {
+	row := q.db.QueryRowContext(ctx, createHello, arg.Message, arg.Author)
 	var i Hello
-	err := row.Scan(&i.ID, &i.Message)
+	err := row.Scan(
+		&i.ID,
+		&i.Message,
+		&i.Author,
+		&i.TenantID,
+	)
 	return i, err
 }
 
-const deleteHello = `-- name: DeleteHello :exec
+const deleteHello = `-- name: DeleteHello :execrows
 DELETE FROM hello
 WHERE id = $1
 `
 
-func (q *Queries) DeleteHello(ctx context.Context, id int32) error {
-	_, err := q.db.ExecContext(ctx, deleteHello, id)
-	return err
+func (q *Queries) DeleteHello(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteHello, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
 const getHello = `-- name: GetHello :one
-SELECT id, message
+SELECT id, message, author, tenant_id
 FROM hello
 WHERE id = $1
 `
@@ -41,12 +54,17 @@ WHERE id = $1
 func (q *Queries) GetHello(ctx context.Context, id int32) (Hello, error) {
 	row := q.db.QueryRowContext(ctx, getHello, id)
 	var i Hello
-	err := row.Scan(&i.ID, &i.Message)
+	err := row.Scan(
+		&i.ID,
+		&i.Message,
+		&i.Author,
+		&i.TenantID,
+	)
 	return i, err
 }
 
 const listHellos = `-- name: ListHellos :many
-SELECT id, message
+SELECT id, message, author, tenant_id
 FROM hello
 ORDER BY id
 LIMIT $1 OFFSET $2
@@ -66,7 +84,12 @@ func (q *Queries) ListHellos(ctx context.Context, arg ListHellosParams) ([]Hello
 	var items []Hello
 	for rows.Next() {
 		var i Hello
-		if err := rows.Scan(&i.ID, &i.Message); err != nil {
+		if err := rows.Scan(
+			&i.ID,
+			&i.Message,
+			&i.Author,
+			&i.TenantID,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -80,7 +103,7 @@ func (q *Queries) ListHellos(ctx context.Context, arg ListHellosParams) ([]Hello
 	return items, nil
 }
 
-const updateHello = `-- name: UpdateHello :exec
+const updateHello = `-- name: UpdateHello :execrows
 UPDATE hello
 SET message = $1
 WHERE id = $2
@@ -91,7 +114,10 @@ type UpdateHelloParams struct {
 	ID      int32  `json:"id"`
 }
 
-func (q *Queries) UpdateHello(ctx context.Context, arg UpdateHelloParams) error {
-	_, err := q.db.ExecContext(ctx, updateHello, arg.Message, arg.ID)
-	return err
+func (q *Queries) UpdateHello(ctx context.Context, arg UpdateHelloParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateHello, arg.Message, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
@@ -5,6 +5,8 @@
 package gen
 
 type Hello struct {
-	ID      int32  `json:"id"`
-	Message string `json:"message"`
+	ID       int32  `json:"id"`
+	Message  string `json:"message"`
+	Author   string `json:"author"`
+	TenantID string `json:"tenant_id"`
 }
@@ -1,25 +1,25 @@
 -- name: CreateHello :one
-INSERT INTO hello (message)
-VALUES ($1)
-RETURNING id, message;
+INSERT INTO hello (message, author)
+VALUES ($1, $2)
+RETURNING id, message, author, tenant_id;
 
 -- name: GetHello :one
-SELECT id, message
+SELECT id, message, author, tenant_id
 FROM hello
 WHERE id = $1;
 
 -- name: ListHellos :many
-SELECT id, message
+SELECT id, message, author, tenant_id
 FROM hello
 ORDER BY id
 LIMIT $1 OFFSET $2;
 
--- name: UpdateHello :exec
+-- name: UpdateHello :execrows
 UPDATE hello
 SET message = $1
 WHERE id = $2;
 
--- name: DeleteHello :exec
+-- name: DeleteHello :execrows
 DELETE FROM hello
 WHERE id = $1;
 
@@ -0,0 +1,2 @@
+ALTER TABLE hello ADD COLUMN author TEXT NOT NULL DEFAULT '';
+
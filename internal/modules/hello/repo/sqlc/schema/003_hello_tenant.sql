@@ -0,0 +1,9 @@
+ALTER TABLE hello ADD COLUMN tenant_id TEXT NOT NULL DEFAULT current_setting('app.tenant_id');
+CREATE INDEX hello_tenant_id_idx ON hello (tenant_id, id);
+
+-- FORCE applies the policy to the table owner as well, as in the migration
+ALTER TABLE hello ENABLE ROW LEVEL SECURITY;
+ALTER TABLE hello FORCE ROW LEVEL SECURITY;
+CREATE POLICY hello_tenant_isolation ON hello
+  USING (tenant_id = current_setting('app.tenant_id', true))
+  WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
@@ -0,0 +1,7 @@
+// Package schema embeds the sqlc schema of the hello module for drift checks.
+package schema
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,138 @@
+// Package sqlite is the hello datastore for the SQLite driver. SQLite has no
+// Row-Level Security, so every query filters on the tenant of the context.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"go.uber.org/zap"
+
+	"github.com/Jexim/HelloGo/internal/modules/hello/model"
+	gen "github.com/Jexim/HelloGo/internal/modules/hello/repo/sqlite/gen"
+	platformdb "github.com/Jexim/HelloGo/internal/platform/db"
+	"github.com/Jexim/HelloGo/internal/platform/logger"
+	"github.com/Jexim/HelloGo/internal/platform/tenant"
+)
+
+type helloDatastore struct {
+	src platformdb.Source
+}
+
+func NewDatastore(src platformdb.Source) model.Datastore {
+	return &helloDatastore{src: src}
+}
+
+// Create creates a new hello in the database
+func (d *helloDatastore) Create(ctx context.Context, in *model.Hello) (*model.Hello, error) {
+	var out *model.Hello
+	err := d.write(ctx, func(q *gen.Queries, tenantID string) error {
+		h, err := q.CreateHello(ctx, gen.CreateHelloParams{Message: in.Message, Author: in.Author, TenantID: tenantID})
+		if err != nil {
+			return err
+		}
+		out = toModel(h)
+		return nil
+	})
+	return out, err
+}
+
+// GetAll retrieves all hellos from the database
+func (d *helloDatastore) GetAll(ctx context.Context, limit, offset int) ([]model.Hello, error) {
+	var result []model.Hello
+	err := d.read(ctx, func(q *gen.Queries, tenantID string) error {
+		list, err := q.ListHellos(ctx, gen.ListHellosParams{TenantID: tenantID, Limit: int64(limit), Offset: int64(offset)})
+		if err != nil {
+			return err
+		}
+		result = make([]model.Hello, 0, len(list))
+		for _, it := range list {
+			result = append(result, *toModel(it))
+		}
+		return nil
+	})
+	return result, err
+}
+
+// Get retrieves a hello by ID from the database
+func (d *helloDatastore) Get(ctx context.Context, id int) (*model.Hello, error) {
+	var out *model.Hello
+	err := d.read(ctx, func(q *gen.Queries, tenantID string) error {
+		h, err := q.GetHello(ctx, gen.GetHelloParams{ID: int64(id), TenantID: tenantID})
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		out = toModel(h)
+		return nil
+	})
+	return out, err
+}
+
+// Update updates a hello in the database
+func (d *helloDatastore) Update(ctx context.Context, id int, in *model.Hello) error {
+	return d.write(ctx, func(q *gen.Queries, tenantID string) error {
+		n, err := q.UpdateHello(ctx, gen.UpdateHelloParams{Message: in.Message, ID: int64(id), TenantID: tenantID})
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return model.ErrNotFound
+		}
+		return nil
+	})
+}
+
+// Delete removes a hello from the database
+func (d *helloDatastore) Delete(ctx context.Context, id int) error {
+	return d.write(ctx, func(q *gen.Queries, tenantID string) error {
+		n, err := q.DeleteHello(ctx, gen.DeleteHelloParams{ID: int64(id), TenantID: tenantID})
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return model.ErrNotFound
+		}
+		return nil
+	})
+}
+
+// read runs fn against the tenant's database with the tenant to filter on
+func (d *helloDatastore) read(ctx context.Context, fn func(q *gen.Queries, tenantID string) error) error {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return err
+	}
+	err = d.src.ReadTx(ctx, func(db platformdb.DBTX) error {
+		return fn(gen.New(db), tenantID)
+	})
+	logFailure(ctx, "read", err)
+	return err
+}
+
+// write runs fn against the tenant's database with the tenant to filter on
+func (d *helloDatastore) write(ctx context.Context, fn func(q *gen.Queries, tenantID string) error) error {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return err
+	}
+	err = d.src.WriteTx(ctx, func(db platformdb.DBTX) error {
+		return fn(gen.New(db), tenantID)
+	})
+	logFailure(ctx, "write", err)
+	return err
+}
+
+// logFailure logs errors other than a missing hello with the request's logger
+func logFailure(ctx context.Context, op string, err error) {
+	if err != nil && !errors.Is(err, model.ErrNotFound) {
+		logger.FromContext(ctx).Warn("hello datastore "+op+" failed", zap.Error(err))
+	}
+}
+
+func toModel(h gen.Hello) *model.Hello {
+	return &model.Hello{ID: uint(h.ID), Message: h.Message, Author: h.Author}
+}
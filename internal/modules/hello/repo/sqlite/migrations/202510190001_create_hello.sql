@@ -0,0 +1,14 @@
+-- +goose Up
+-- SQLite has no Row-Level Security, so the queries filter on tenant_id themselves.
+-- AUTOINCREMENT keeps ids from being reused, like the Postgres sequence.
+CREATE TABLE IF NOT EXISTS hello (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  message TEXT NOT NULL,
+  author TEXT NOT NULL DEFAULT '',
+  tenant_id TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS hello_tenant_id_idx ON hello (tenant_id, id);
+
+-- +goose Down
+DROP INDEX IF EXISTS hello_tenant_id_idx;
+DROP TABLE IF EXISTS hello;
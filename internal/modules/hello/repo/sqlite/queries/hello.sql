@@ -0,0 +1,26 @@
+-- name: CreateHello :one
+INSERT INTO hello (message, author, tenant_id)
+VALUES (?, ?, ?)
+RETURNING id, message, author, tenant_id;
+
+-- name: GetHello :one
+SELECT id, message, author, tenant_id
+FROM hello
+WHERE id = ? AND tenant_id = ?;
+
+-- name: ListHellos :many
+SELECT id, message, author, tenant_id
+FROM hello
+WHERE tenant_id = ?
+ORDER BY id
+LIMIT ? OFFSET ?;
+
+-- name: UpdateHello :execrows
+UPDATE hello
+SET message = ?
+WHERE id = ? AND tenant_id = ?;
+
+-- name: DeleteHello :execrows
+DELETE FROM hello
+WHERE id = ? AND tenant_id = ?;
+
@@ -1,6 +1,9 @@
 package rest
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 
@@ -9,6 +12,9 @@ import (
 	httpmw "github.com/Jexim/HelloGo/internal/adapter/http/middleware"
 	httprespond "github.com/Jexim/HelloGo/internal/adapter/http/respond"
 	"github.com/Jexim/HelloGo/internal/modules/hello/model"
+	"github.com/Jexim/HelloGo/internal/platform/apperr"
+	"github.com/Jexim/HelloGo/internal/platform/auth"
+	"github.com/Jexim/HelloGo/internal/platform/tenant"
 )
 
 // @title Hello Service API
@@ -20,16 +26,59 @@ type REST struct {
 	helloUC model.Usecase
 }
 
-func New(mux *chi.Mux, prefix string, helloUC model.Usecase) model.REST {
+// New mounts the hello routes. Route-level guards reject callers without a
+// suitable scope before the response cache, so cache hits are authorized and
+// logged too; the usecase policy then applies its ownership rules.
+func New(mux *chi.Mux, prefix string, helloUC model.Usecase, az *auth.Authorizer, rc *httpmw.ResponseCache) model.REST {
 	rest := &REST{helloUC: helloUC}
 
+	canRead := httpmw.RequireScope(az, "hello.read", model.ScopeRead, model.ScopeWrite, model.ScopeAdmin)
+	canCreate := httpmw.RequireScope(az, "hello.create", model.ScopeWrite, model.ScopeAdmin)
+	canUpdate := httpmw.RequireScope(az, "hello.update", model.ScopeWrite, model.ScopeAdmin)
+	canDelete := httpmw.RequireScope(az, "hello.delete", model.ScopeWrite, model.ScopeAdmin)
+
+	// Responses differ per tenant and caller, so clients keep them private and
+	// revalidate; writes purge the surrogate keys of the tenant's lists and the
+	// hello. The module-wide key is only for operators purging every tenant.
+	vary := []string{"Authorization", httpmw.APIKeyHeader}
+	cacheList := rc.Cache(httpmw.CachePolicy{Private: true, Vary: vary, SurrogateKeys: withModuleKey(listKeys)})
+	cacheItem := rc.Cache(httpmw.CachePolicy{Private: true, Vary: vary, SurrogateKeys: withModuleKey(itemKeys)})
+	purgeList := rc.Invalidate(listKeys)
+	purgeItem := rc.Invalidate(itemKeys)
+
 	mux.Route(prefix, func(r chi.Router) {
-		r.Get("/", rest.ListHellos)
+		r.With(canRead, cacheList).Get("/", rest.ListHellos)
+		r.With(canRead, cacheItem).Get("/{id}", rest.GetHello)
+		r.With(canCreate, purgeList).Post("/", rest.CreateHello)
+		r.With(canUpdate, purgeItem).Put("/{id}", rest.UpdateHello)
+		r.With(canDelete, purgeItem).Delete("/{id}", rest.DeleteHello)
 	})
 
 	return rest
 }
 
+// listKeys are the surrogate keys of the tenant's hello lists
+func listKeys(req *http.Request) []string {
+	return []string{model.SurrogateKey + ":" + tenant.FromContext(req.Context()) + ":list"}
+}
+
+// itemKeys are the surrogate keys of a single hello, which also appears in the lists
+func itemKeys(req *http.Request) []string {
+	t := tenant.FromContext(req.Context())
+	return append(listKeys(req), model.SurrogateKey+":"+t+":"+chi.URLParam(req, "id"))
+}
+
+// withModuleKey adds model.SurrogateKey to the tags of keys
+func withModuleKey(keys func(req *http.Request) []string) func(req *http.Request) []string {
+	return func(req *http.Request) []string {
+		return append(keys(req), model.SurrogateKey)
+	}
+}
+
+type helloRequest struct {
+	Message string `json:"message"`
+}
+
 // @Summary Get Hello
 // @Description Get Hello
 // @Accept json
@@ -53,15 +102,121 @@ func (r *REST) ListHellos(w http.ResponseWriter, req *http.Request) {
 
 	items, err := r.helloUC.GetAll(req.Context(), limit, offset)
 	if err != nil {
-		status, code, msg := httpmw.MapError(err)
-		httprespond.JSON(w, status, map[string]any{
-			"error": map[string]any{
-				"code":     code,
-				"message":  msg,
-				"trace_id": httpmw.GetTraceID(req),
-			},
-		})
+		httpmw.RespondError(w, req, err)
 		return
 	}
 	httprespond.JSON(w, http.StatusOK, items)
 }
+
+// @Summary Get Hello by ID
+// @Description Get a single Hello
+// @Produce json
+// @Param id path int true "Hello ID"
+// @Success 200 {object} model.Hello
+// @Router /hello/{id} [get]
+// GetHello returns a single hello
+func (r *REST) GetHello(w http.ResponseWriter, req *http.Request) {
+	id, err := parseID(req)
+	if err != nil {
+		httpmw.RespondError(w, req, err)
+		return
+	}
+	h, err := r.helloUC.Get(req.Context(), id)
+	if err != nil {
+		httpmw.RespondError(w, req, err)
+		return
+	}
+	httprespond.JSON(w, http.StatusOK, h)
+}
+
+// @Summary Create Hello
+// @Description Create a Hello authored by the caller
+// @Accept json
+// @Produce json
+// @Success 201 {object} model.Hello
+// @Router /hello [post]
+// CreateHello stores a new hello
+func (r *REST) CreateHello(w http.ResponseWriter, req *http.Request) {
+	in, err := decodeHello(req)
+	if err != nil {
+		httpmw.RespondError(w, req, err)
+		return
+	}
+	h, err := r.helloUC.Create(req.Context(), in)
+	if err != nil {
+		httpmw.RespondError(w, req, err)
+		return
+	}
+	httprespond.JSON(w, http.StatusCreated, h)
+}
+
+// @Summary Update Hello
+// @Description Update the message of a Hello; only its author or an admin may do so
+// @Accept json
+// @Param id path int true "Hello ID"
+// @Success 204
+// @Failure 403
+// @Failure 404
+// @Router /hello/{id} [put]
+// UpdateHello changes the message of a hello
+func (r *REST) UpdateHello(w http.ResponseWriter, req *http.Request) {
+	id, err := parseID(req)
+	if err != nil {
+		httpmw.RespondError(w, req, err)
+		return
+	}
+	in, err := decodeHello(req)
+	if err != nil {
+		httpmw.RespondError(w, req, err)
+		return
+	}
+	if err := r.helloUC.Update(req.Context(), id, in); err != nil {
+		httpmw.RespondError(w, req, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Delete Hello
+// @Description Delete a Hello; only its author or an admin may do so
+// @Param id path int true "Hello ID"
+// @Success 204
+// @Failure 403
+// @Failure 404
+// @Router /hello/{id} [delete]
+// DeleteHello removes a hello
+func (r *REST) DeleteHello(w http.ResponseWriter, req *http.Request) {
+	id, err := parseID(req)
+	if err != nil {
+		httpmw.RespondError(w, req, err)
+		return
+	}
+	if err := r.helloUC.Delete(req.Context(), id); err != nil {
+		httpmw.RespondError(w, req, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func parseID(req *http.Request) (int, error) {
+	id, err := strconv.Atoi(chi.URLParam(req, "id"))
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("%w: invalid id", apperr.ErrBadRequest)
+	}
+	return id, nil
+}
+
+func decodeHello(req *http.Request) (*model.Hello, error) {
+	var body helloRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, fmt.Errorf("%w: body exceeds %d bytes", apperr.ErrTooLarge, tooLarge.Limit)
+		}
+		return nil, fmt.Errorf("%w: invalid body", apperr.ErrBadRequest)
+	}
+	if body.Message == "" {
+		return nil, fmt.Errorf("%w: message is required", apperr.ErrBadRequest)
+	}
+	return &model.Hello{Message: body.Message}, nil
+}
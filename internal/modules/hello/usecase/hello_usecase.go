@@ -1,13 +1,113 @@
 package usecase
 
-import "github.com/Jexim/HelloGo/internal/modules/hello/model"
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/Jexim/HelloGo/internal/modules/hello/model"
+	"github.com/Jexim/HelloGo/internal/platform/auth"
+	"github.com/Jexim/HelloGo/internal/platform/logger"
+	"github.com/Jexim/HelloGo/internal/platform/tracing"
+)
 
 type Usecase struct {
-	model.Datastore
+	ds     model.Datastore
+	policy model.Policy
 }
 
-func New(ds model.Datastore) *Usecase {
+func New(ds model.Datastore, policy model.Policy) *Usecase {
 	return &Usecase{
-		Datastore: ds,
+		ds:     ds,
+		policy: policy,
+	}
+}
+
+// Create stores a new hello authored by the calling principal
+func (u *Usecase) Create(ctx context.Context, in *model.Hello) (_ *model.Hello, err error) {
+	ctx, span := tracing.Start(ctx, "hello.Create")
+	defer func() { tracing.End(span, err) }()
+
+	if err := u.policy.CanCreate(ctx); err != nil {
+		return nil, err
+	}
+	h := *in
+	h.Author = ""
+	if p := auth.FromContext(ctx); p != nil {
+		h.Author = p.Subject
+	}
+	out, err := u.ds.Create(ctx, &h)
+	if err != nil {
+		return nil, err
+	}
+	logger.FromContext(ctx).Info("hello created", zap.Uint("id", out.ID), zap.String("author", out.Author))
+	return out, nil
+}
+
+// GetAll returns a page of hellos
+func (u *Usecase) GetAll(ctx context.Context, limit, offset int) (_ []model.Hello, err error) {
+	ctx, span := tracing.Start(ctx, "hello.GetAll")
+	defer func() { tracing.End(span, err) }()
+
+	if err := u.policy.CanRead(ctx); err != nil {
+		return nil, err
+	}
+	return u.ds.GetAll(ctx, limit, offset)
+}
+
+// Get returns a single hello
+func (u *Usecase) Get(ctx context.Context, id int) (_ *model.Hello, err error) {
+	ctx, span := tracing.Start(ctx, "hello.Get", trace.WithAttributes(attribute.Int("hello.id", id)))
+	defer func() { tracing.End(span, err) }()
+
+	if err := u.policy.CanRead(ctx); err != nil {
+		return nil, err
+	}
+	return u.ds.Get(ctx, id)
+}
+
+// Update changes the message of an existing hello
+func (u *Usecase) Update(ctx context.Context, id int, in *model.Hello) (err error) {
+	ctx, span := tracing.Start(ctx, "hello.Update", trace.WithAttributes(attribute.Int("hello.id", id)))
+	defer func() { tracing.End(span, err) }()
+
+	if err := u.policy.CanUpdate(ctx, nil); err != nil {
+		return err
+	}
+	existing, err := u.ds.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := u.policy.CanUpdate(ctx, existing); err != nil {
+		return err
+	}
+	if err := u.ds.Update(ctx, id, in); err != nil {
+		return err
+	}
+	logger.FromContext(ctx).Info("hello updated", zap.Int("id", id))
+	return nil
+}
+
+// Delete removes an existing hello
+func (u *Usecase) Delete(ctx context.Context, id int) (err error) {
+	ctx, span := tracing.Start(ctx, "hello.Delete", trace.WithAttributes(attribute.Int("hello.id", id)))
+	defer func() { tracing.End(span, err) }()
+
+	if err := u.policy.CanDelete(ctx, nil); err != nil {
+		return err
+	}
+	existing, err := u.ds.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := u.policy.CanDelete(ctx, existing); err != nil {
+		return err
+	}
+	if err := u.ds.Delete(ctx, id); err != nil {
+		return err
 	}
+	logger.FromContext(ctx).Info("hello deleted", zap.Int("id", id))
+	return nil
 }
@@ -6,4 +6,8 @@ var (
 	ErrAlreadyExists = errors.New("already exists")
 	ErrNotFound      = errors.New("not found")
 	ErrBadRequest    = errors.New("bad request")
+	ErrUnauthorized  = errors.New("unauthorized")
+	ErrForbidden     = errors.New("forbidden")
+	ErrRateLimited   = errors.New("rate limited")
+	ErrTooLarge      = errors.New("payload too large")
 )
@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"context"
+	"slices"
+)
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	Subject string            `json:"subject"`
+	Scopes  []string          `json:"scopes"`
+	Claims  map[string]string `json:"claims,omitempty"`
+}
+
+// HasScope reports whether the principal holds at least one of the given scopes
+func (p *Principal) HasScope(scopes ...string) bool {
+	if p == nil {
+		return false
+	}
+	for _, s := range scopes {
+		if slices.Contains(p.Scopes, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Anonymous reports whether the principal represents an unauthenticated caller
+func (p *Principal) Anonymous() bool {
+	return p == nil || p.Subject == ""
+}
+
+type contextKey string
+
+const principalContextKey contextKey = "principal"
+
+// WithPrincipal stores the principal in the context
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey, p)
+}
+
+// FromContext returns the principal stored in the context, or nil
+func FromContext(ctx context.Context) *Principal {
+	if p, ok := ctx.Value(principalContextKey).(*Principal); ok {
+		return p
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"github.com/Jexim/HelloGo/internal/platform/apperr"
+	"github.com/Jexim/HelloGo/internal/platform/tracing"
+)
+
+// Authorizer records authorization decisions and turns denials into apperr.ErrForbidden
+type Authorizer struct {
+	logger *zap.Logger
+}
+
+func NewAuthorizer(logger *zap.Logger) *Authorizer {
+	return &Authorizer{logger: logger}
+}
+
+// Decide logs the decision for action and returns apperr.ErrForbidden when it is denied
+func (a *Authorizer) Decide(ctx context.Context, action string, allowed bool, reason string) error {
+	p := FromContext(ctx)
+	subject := ""
+	if p != nil {
+		subject = p.Subject
+	}
+	fields := []zap.Field{
+		zap.String("action", action),
+		zap.String("subject", subject),
+		zap.Bool("allowed", allowed),
+		zap.String("reason", reason),
+		zap.String("trace_id", tracing.TraceID(ctx)),
+	}
+	if !allowed {
+		a.logger.Warn("authorization_denied", fields...)
+		return apperr.ErrForbidden
+	}
+	a.logger.Info("authorization_granted", fields...)
+	return nil
+}
+
+// RequireScope allows action when the principal in ctx holds any of scopes
+func (a *Authorizer) RequireScope(ctx context.Context, action string, scopes ...string) error {
+	if FromContext(ctx).HasScope(scopes...) {
+		return a.Decide(ctx, action, true, "scope")
+	}
+	return a.Decide(ctx, action, false, "missing scope")
+}
@@ -0,0 +1,54 @@
+// Package cache provides the key/value stores read-through caches are built on.
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Jexim/HelloGo/internal/platform/config"
+	"github.com/Jexim/HelloGo/internal/platform/metrics"
+)
+
+const (
+	// BackendMemory keeps entries in an in-process LRU
+	BackendMemory = "memory"
+)
+
+const (
+	defaultSize = 1024
+	defaultTTL  = time.Minute
+)
+
+// Store is a key/value cache with per-entry TTLs. Values are opaque bytes so
+// a shared backend such as Redis can implement it with GET, SET EX and DEL.
+type Store interface {
+	// Get returns the value of key and whether it was found
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores value under key for ttl
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes keys; missing keys are ignored
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// New creates the store configured by cc; name labels its metrics in m
+func New(name string, cc config.CacheConfig, m *metrics.Metrics) (Store, error) {
+	size := cc.Size
+	if size <= 0 {
+		size = defaultSize
+	}
+	switch cc.Backend {
+	case "", BackendMemory:
+		return NewLRU(name, size, m), nil
+	default:
+		return nil, fmt.Errorf("unknown cache backend %q", cc.Backend)
+	}
+}
+
+// TTL returns the entry lifetime configured by cc
+func TTL(cc config.CacheConfig) time.Duration {
+	if cc.TTL <= 0 {
+		return defaultTTL
+	}
+	return cc.TTL
+}
@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Jexim/HelloGo/internal/platform/metrics"
+)
+
+type lruEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// LRU is an in-process Store holding at most size entries. The least recently
+// used entry is evicted when it is full; expired entries are dropped when read.
+type LRU struct {
+	name    string
+	size    int
+	metrics *metrics.Metrics
+	mu      sync.Mutex
+	order   *list.List
+	items   map[string]*list.Element
+}
+
+// NewLRU creates an LRU recording its evictions to m under name
+func NewLRU(name string, size int, m *metrics.Metrics) *LRU {
+	return &LRU{name: name, size: size, metrics: m, order: list.New(), items: make(map[string]*list.Element)}
+}
+
+func (c *LRU) Get(_ context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false, nil
+	}
+	e := el.Value.(*lruEntry)
+	if time.Now().After(e.expires) {
+		c.remove(el)
+		c.metrics.CacheEvictions.WithLabelValues(c.name, "expired").Inc()
+		return nil, false, nil
+	}
+	c.order.MoveToFront(el)
+	return e.value, true, nil
+}
+
+func (c *LRU) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	expires := time.Now().Add(ttl)
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*lruEntry)
+		e.value, e.expires = value, expires
+		c.order.MoveToFront(el)
+		return nil
+	}
+	c.items[key] = c.order.PushFront(&lruEntry{key: key, value: value, expires: expires})
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+		c.metrics.CacheEvictions.WithLabelValues(c.name, "capacity").Inc()
+	}
+	return nil
+}
+
+func (c *LRU) Delete(_ context.Context, keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		if el, ok := c.items[key]; ok {
+			c.remove(el)
+		}
+	}
+	return nil
+}
+
+// Len returns the number of entries, including expired ones not read since
+func (c *LRU) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *LRU) remove(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.items, el.Value.(*lruEntry).key)
+}
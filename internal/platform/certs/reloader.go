@@ -0,0 +1,151 @@
+// Package certs serves TLS certificates that are reloaded when their files change.
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+
+	"github.com/Jexim/HelloGo/internal/platform/config"
+)
+
+const (
+	// ClientAuthNone ignores client certificates
+	ClientAuthNone = "none"
+	// ClientAuthVerify verifies client certificates that are sent
+	ClientAuthVerify = "verify"
+	// ClientAuthRequire rejects connections without a valid client certificate
+	ClientAuthRequire = "require"
+)
+
+type material struct {
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+}
+
+// Reloader holds the server certificate and client CA bundle of cfg and
+// swaps them when the files are rewritten, so rotated certificates are
+// picked up without a restart. Connections already open keep their certificate.
+type Reloader struct {
+	cfg        config.TLSConfig
+	clientAuth tls.ClientAuthType
+	current    atomic.Pointer[material]
+	logger     *zap.Logger
+}
+
+func NewReloader(cfg config.TLSConfig, logger *zap.Logger) (*Reloader, error) {
+	r := &Reloader{cfg: cfg, logger: logger}
+	switch cfg.ClientAuth {
+	case "", ClientAuthNone:
+		r.clientAuth = tls.NoClientCert
+	case ClientAuthVerify:
+		r.clientAuth = tls.VerifyClientCertIfGiven
+	case ClientAuthRequire:
+		r.clientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return nil, fmt.Errorf("unknown client auth %q", cfg.ClientAuth)
+	}
+	if r.clientAuth != tls.NoClientCert && cfg.ClientCAFile == "" {
+		return nil, fmt.Errorf("client auth %q needs a client CA file", cfg.ClientAuth)
+	}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// TLSConfig returns a server config that always uses the latest certificates
+func (r *Reloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			m := r.current.Load()
+			return &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*m.cert},
+				ClientAuth:   r.clientAuth,
+				ClientCAs:    m.clientCAs,
+				NextProtos:   []string{"h2", "http/1.1"},
+			}, nil
+		},
+	}
+}
+
+// Watch reloads the files whenever they change until ctx ends. The
+// directories are watched rather than the files, so files replaced by a
+// rename or a symlink swap, as Kubernetes does for secrets, are noticed too.
+func (r *Reloader) Watch(ctx context.Context) error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	dirs := map[string]bool{}
+	for _, f := range []string{r.cfg.CertFile, r.cfg.KeyFile, r.cfg.ClientCAFile} {
+		if f != "" {
+			dirs[filepath.Dir(f)] = true
+		}
+	}
+	for dir := range dirs {
+		if err := w.Add(dir); err != nil {
+			_ = w.Close()
+			return fmt.Errorf("watch %s: %w", dir, err)
+		}
+	}
+
+	go func() {
+		defer w.Close()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case ev, ok := <-w.Events:
+				if !ok {
+					return
+				}
+				if ev.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename|fsnotify.Remove) == 0 {
+					continue
+				}
+				// A half-written pair fails to load; the last good one stays in use
+				// until the next event completes it
+				if err := r.load(); err != nil {
+					r.logger.Warn("failed to reload TLS certificates", zap.String("file", ev.Name), zap.Error(err))
+					continue
+				}
+				r.logger.Info("reloaded TLS certificates", zap.String("file", ev.Name))
+			case err, ok := <-w.Errors:
+				if !ok {
+					return
+				}
+				r.logger.Warn("TLS certificate watcher failed", zap.Error(err))
+			}
+		}
+	}()
+	return nil
+}
+
+func (r *Reloader) load() error {
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return fmt.Errorf("load certificate: %w", err)
+	}
+	m := &material{cert: &cert}
+	if r.cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.cfg.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("read client CA bundle: %w", err)
+		}
+		m.clientCAs = x509.NewCertPool()
+		if !m.clientCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("client CA bundle %s holds no certificates", r.cfg.ClientCAFile)
+		}
+	}
+	r.current.Store(m)
+	return nil
+}
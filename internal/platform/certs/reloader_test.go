@@ -0,0 +1,120 @@
+package certs
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Jexim/HelloGo/internal/platform/config"
+)
+
+func TestReloaderWatchRotatesCertificate(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.TLSConfig{CertFile: filepath.Join(dir, "tls.crt"), KeyFile: filepath.Join(dir, "tls.key")}
+	writePair(t, cfg, "first")
+
+	r, err := NewReloader(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewReloader: %v", err)
+	}
+	if got := serverName(t, r); got != "first" {
+		t.Fatalf("certificate = %q, want first", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := r.Watch(ctx); err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+
+	// A broken file keeps the last good certificate in use
+	if err := os.WriteFile(cfg.CertFile, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if got := serverName(t, r); got != "first" {
+		t.Fatalf("certificate after broken write = %q, want first", got)
+	}
+
+	// Files replaced by a rename, as secret mounts do, are picked up
+	writePair(t, cfg, "second")
+	deadline := time.Now().Add(5 * time.Second)
+	for serverName(t, r) != "second" {
+		if time.Now().After(deadline) {
+			t.Fatal("rotated certificate was not loaded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestNewReloaderRejectsClientAuthWithoutCA(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.TLSConfig{CertFile: filepath.Join(dir, "tls.crt"), KeyFile: filepath.Join(dir, "tls.key"), ClientAuth: ClientAuthRequire}
+	writePair(t, cfg, "server")
+	if _, err := NewReloader(cfg, zap.NewNop()); err == nil {
+		t.Fatal("NewReloader accepted client auth without a client CA file")
+	}
+}
+
+// serverName returns the common name of the certificate r currently serves
+func serverName(t *testing.T, r *Reloader) string {
+	t.Helper()
+	c, err := r.TLSConfig().GetConfigForClient(&tls.ClientHelloInfo{})
+	if err != nil {
+		t.Fatalf("GetConfigForClient: %v", err)
+	}
+	leaf, err := x509.ParseCertificate(c.Certificates[0].Certificate[0])
+	if err != nil {
+		t.Fatalf("parse certificate: %v", err)
+	}
+	return leaf.Subject.CommonName
+}
+
+// writePair writes a self-signed certificate for commonName and its key to the
+// files of cfg, each through a temporary file renamed into place
+func writePair(t *testing.T, cfg config.TLSConfig, commonName string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	tmpl.Subject.CommonName = commonName
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The key goes first, so the certificate completes the pair
+	replace(t, cfg.KeyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+	replace(t, cfg.CertFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func replace(t *testing.T, path string, data []byte) {
+	t.Helper()
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+}
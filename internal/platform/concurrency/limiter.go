@@ -0,0 +1,217 @@
+// Package concurrency limits how many requests run at once with an adaptive
+// (AIMD) limit and sheds the excess by priority.
+package concurrency
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Jexim/HelloGo/internal/platform/metrics"
+)
+
+// Priority orders requests competing for the limit; higher priorities are admitted first
+type Priority int
+
+const (
+	PriorityLow Priority = iota
+	PriorityNormal
+	PriorityHigh
+	// PriorityCritical is never limited nor shed
+	PriorityCritical
+)
+
+var priorityNames = map[Priority]string{
+	PriorityLow:      "low",
+	PriorityNormal:   "normal",
+	PriorityHigh:     "high",
+	PriorityCritical: "critical",
+}
+
+func (p Priority) String() string {
+	return priorityNames[p]
+}
+
+// ParsePriority returns the priority named s
+func ParsePriority(s string) (Priority, bool) {
+	for p, name := range priorityNames {
+		if name == s {
+			return p, true
+		}
+	}
+	return PriorityNormal, false
+}
+
+// ErrShed is returned for requests the limiter rejects
+var ErrShed = errors.New("request shed")
+
+// Options tune a Limiter
+type Options struct {
+	InitialLimit int
+	MinLimit     int
+	MaxLimit     int
+	// LatencyThreshold is the latency above which a request counts as a sign of overload
+	LatencyThreshold time.Duration
+	// Backoff multiplies the limit on overload, e.g. 0.9
+	Backoff float64
+	// MaxQueue is how many requests may wait for a slot
+	MaxQueue int
+	// QueueTimeout is how long a request waits before it is shed
+	QueueTimeout time.Duration
+}
+
+type waiter struct {
+	priority Priority
+	ready    chan error
+}
+
+// Limiter admits requests while fewer than its limit are in flight. The limit
+// grows by one per limit's worth of fast requests and shrinks by Backoff when
+// one is slow or failed. Excess requests wait briefly in a queue served by priority;
+// when it is full the lowest priority request is shed.
+type Limiter struct {
+	opts     Options
+	metrics  *metrics.Metrics
+	mu       sync.Mutex
+	limit    float64
+	inflight int
+	queue    *list.List
+}
+
+// NewLimiter creates a limiter with opts, recording its limit, queue and
+// shed requests to m
+func NewLimiter(opts Options, m *metrics.Metrics) *Limiter {
+	if opts.MinLimit <= 0 {
+		opts.MinLimit = 1
+	}
+	if opts.MaxLimit < opts.MinLimit {
+		opts.MaxLimit = opts.MinLimit
+	}
+	if opts.InitialLimit < opts.MinLimit || opts.InitialLimit > opts.MaxLimit {
+		opts.InitialLimit = opts.MinLimit
+	}
+	if opts.Backoff <= 0 || opts.Backoff >= 1 {
+		opts.Backoff = 0.9
+	}
+	l := &Limiter{opts: opts, metrics: m, limit: float64(opts.InitialLimit), queue: list.New()}
+	m.ConcurrencyLimit.Set(l.limit)
+	return l
+}
+
+// Acquire waits for a slot and returns the func releasing it, which must be called
+// with the request's latency and whether it failed once it is done. It returns
+// ErrShed when the request is rejected, or the context's error when ctx ends
+// while it waits.
+func (l *Limiter) Acquire(ctx context.Context, p Priority) (func(latency time.Duration, failed bool), error) {
+	if p >= PriorityCritical {
+		return func(time.Duration, bool) {}, nil
+	}
+
+	l.mu.Lock()
+	if l.inflight < int(l.limit) && l.queue.Len() == 0 {
+		l.inflight++
+		l.mu.Unlock()
+		return l.release, nil
+	}
+	if l.queue.Len() >= l.opts.MaxQueue && !l.evictBelow(p) {
+		l.mu.Unlock()
+		l.metrics.ConcurrencyShed.WithLabelValues(p.String()).Inc()
+		return nil, ErrShed
+	}
+	w := &waiter{priority: p, ready: make(chan error, 1)}
+	el := l.enqueue(w)
+	l.mu.Unlock()
+
+	timer := time.NewTimer(l.opts.QueueTimeout)
+	defer timer.Stop()
+	var err error
+	select {
+	case err = <-w.ready:
+	case <-timer.C:
+		err = l.giveUp(w, el, ErrShed)
+	case <-ctx.Done():
+		err = l.giveUp(w, el, ctx.Err())
+	}
+	if err != nil {
+		if errors.Is(err, ErrShed) {
+			l.metrics.ConcurrencyShed.WithLabelValues(p.String()).Inc()
+		}
+		return nil, err
+	}
+	return l.release, nil
+}
+
+// giveUp takes a waiter that stopped waiting with err out of the queue. If it
+// was admitted or evicted in the meantime, the outcome it was sent wins.
+func (l *Limiter) giveUp(w *waiter, el *list.Element, err error) error {
+	l.mu.Lock()
+	removed := l.remove(el)
+	l.mu.Unlock()
+	if removed {
+		return err
+	}
+	return <-w.ready
+}
+
+// Limit returns the current concurrency limit
+func (l *Limiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.limit)
+}
+
+func (l *Limiter) release(latency time.Duration, failed bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inflight--
+	if failed || (l.opts.LatencyThreshold > 0 && latency > l.opts.LatencyThreshold) {
+		l.limit = max(float64(l.opts.MinLimit), l.limit*l.opts.Backoff)
+	} else if l.inflight+1 >= int(l.limit) {
+		// Only grow while the limit is actually reached
+		l.limit = min(float64(l.opts.MaxLimit), l.limit+1/l.limit)
+	}
+	l.metrics.ConcurrencyLimit.Set(l.limit)
+	for l.inflight < int(l.limit) && l.queue.Len() > 0 {
+		w := l.queue.Remove(l.queue.Front()).(*waiter)
+		l.metrics.ConcurrencyQueueDepth.WithLabelValues(w.priority.String()).Dec()
+		l.inflight++
+		w.ready <- nil
+	}
+}
+
+// enqueue inserts w behind the waiters of its priority and above lower ones
+func (l *Limiter) enqueue(w *waiter) *list.Element {
+	l.metrics.ConcurrencyQueueDepth.WithLabelValues(w.priority.String()).Inc()
+	for el := l.queue.Front(); el != nil; el = el.Next() {
+		if el.Value.(*waiter).priority < w.priority {
+			return l.queue.InsertBefore(w, el)
+		}
+	}
+	return l.queue.PushBack(w)
+}
+
+// evictBelow sheds the newest waiter of the lowest priority if it is below p
+func (l *Limiter) evictBelow(p Priority) bool {
+	el := l.queue.Back()
+	if el == nil || el.Value.(*waiter).priority >= p {
+		return false
+	}
+	w := l.queue.Remove(el).(*waiter)
+	l.metrics.ConcurrencyQueueDepth.WithLabelValues(w.priority.String()).Dec()
+	w.ready <- ErrShed
+	return true
+}
+
+// remove takes el out of the queue unless it was already admitted or evicted
+func (l *Limiter) remove(el *list.Element) bool {
+	for e := l.queue.Front(); e != nil; e = e.Next() {
+		if e == el {
+			w := l.queue.Remove(el).(*waiter)
+			l.metrics.ConcurrencyQueueDepth.WithLabelValues(w.priority.String()).Dec()
+			return true
+		}
+	}
+	return false
+}
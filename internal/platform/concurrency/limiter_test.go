@@ -0,0 +1,208 @@
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/Jexim/HelloGo/internal/platform/config"
+	"github.com/Jexim/HelloGo/internal/platform/metrics"
+)
+
+func newLimiter(opts Options) *Limiter {
+	return NewLimiter(opts, metrics.New(prometheus.NewRegistry(), config.MetricsConfig{}))
+}
+
+func TestLimiterAIMD(t *testing.T) {
+	type release struct {
+		latency time.Duration
+		failed  bool
+	}
+	cases := []struct {
+		name     string
+		inflight int
+		releases []release
+		want     float64
+	}{
+		{"fast requests at the limit grow it", 4, []release{{latency: time.Millisecond}}, 4.25},
+		{"fast requests below the limit keep it", 1, []release{{latency: time.Millisecond}}, 4},
+		{"slow request backs off", 1, []release{{latency: time.Second}}, 2},
+		{"failed request backs off", 1, []release{{latency: time.Millisecond, failed: true}}, 2},
+		{"backoff stops at the minimum", 3, []release{{latency: time.Second}, {latency: time.Second}, {latency: time.Second}}, 1},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			l := newLimiter(Options{InitialLimit: 4, MinLimit: 1, MaxLimit: 10, LatencyThreshold: 100 * time.Millisecond, Backoff: 0.5})
+			var releases []func(time.Duration, bool)
+			for i := 0; i < c.inflight; i++ {
+				rel, err := l.Acquire(context.Background(), PriorityNormal)
+				if err != nil {
+					t.Fatalf("Acquire %d: %v", i, err)
+				}
+				releases = append(releases, rel)
+			}
+			for i, r := range c.releases {
+				releases[i](r.latency, r.failed)
+			}
+			if l.limit != c.want {
+				t.Fatalf("limit = %v, want %v", l.limit, c.want)
+			}
+		})
+	}
+
+	// The limit never grows past MaxLimit
+	l := newLimiter(Options{InitialLimit: 2, MinLimit: 1, MaxLimit: 2})
+	rel1, _ := l.Acquire(context.Background(), PriorityNormal)
+	rel2, _ := l.Acquire(context.Background(), PriorityNormal)
+	rel2(0, false)
+	rel1(0, false)
+	if got := l.Limit(); got != 2 {
+		t.Fatalf("limit = %d, want MaxLimit 2", got)
+	}
+}
+
+func TestLimiterAdmitsHigherPriorityFirst(t *testing.T) {
+	l := newLimiter(Options{InitialLimit: 1, MaxLimit: 1, MaxQueue: 10, QueueTimeout: time.Minute})
+	hold, err := l.Acquire(context.Background(), PriorityNormal)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	admitted := make(chan Priority, 3)
+	var wg sync.WaitGroup
+	for _, p := range []Priority{PriorityLow, PriorityNormal, PriorityHigh} {
+		wg.Add(1)
+		go func(p Priority) {
+			defer wg.Done()
+			rel, err := l.Acquire(context.Background(), p)
+			if err != nil {
+				t.Errorf("Acquire %s: %v", p, err)
+				return
+			}
+			admitted <- p
+			rel(0, false)
+		}(p)
+		waitQueued(t, l, int(p)+1)
+	}
+
+	hold(0, false)
+	wg.Wait()
+	close(admitted)
+	var order []Priority
+	for p := range admitted {
+		order = append(order, p)
+	}
+	want := []Priority{PriorityHigh, PriorityNormal, PriorityLow}
+	for i := range want {
+		if i >= len(order) || order[i] != want[i] {
+			t.Fatalf("admitted %v, want %v", order, want)
+		}
+	}
+}
+
+func TestLimiterShedsLowestPriorityWhenQueueIsFull(t *testing.T) {
+	l := newLimiter(Options{InitialLimit: 1, MaxLimit: 1, MaxQueue: 1, QueueTimeout: time.Minute})
+	hold, _ := l.Acquire(context.Background(), PriorityNormal)
+
+	low := make(chan error, 1)
+	go func() {
+		_, err := l.Acquire(context.Background(), PriorityLow)
+		low <- err
+	}()
+	waitQueued(t, l, 1)
+
+	// An equal priority finds the queue full
+	if _, err := l.Acquire(context.Background(), PriorityLow); !errors.Is(err, ErrShed) {
+		t.Fatalf("Acquire with a full queue = %v, want ErrShed", err)
+	}
+	// A higher one takes the place of the queued low priority request
+	high := make(chan error, 1)
+	go func() {
+		rel, err := l.Acquire(context.Background(), PriorityHigh)
+		if err == nil {
+			rel(0, false)
+		}
+		high <- err
+	}()
+	if err := <-low; !errors.Is(err, ErrShed) {
+		t.Fatalf("evicted request = %v, want ErrShed", err)
+	}
+	hold(0, false)
+	if err := <-high; err != nil {
+		t.Fatalf("high priority request = %v", err)
+	}
+}
+
+func TestLimiterQueueTimeout(t *testing.T) {
+	l := newLimiter(Options{InitialLimit: 1, MaxLimit: 1, MaxQueue: 1, QueueTimeout: 10 * time.Millisecond})
+	hold, _ := l.Acquire(context.Background(), PriorityNormal)
+	defer hold(0, false)
+
+	if _, err := l.Acquire(context.Background(), PriorityNormal); !errors.Is(err, ErrShed) {
+		t.Fatalf("Acquire after the queue timeout = %v, want ErrShed", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := l.Acquire(ctx, PriorityNormal); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Acquire with a cancelled context = %v, want context.Canceled", err)
+	}
+	if n := l.queue.Len(); n != 0 {
+		t.Fatalf("%d waiters left in the queue", n)
+	}
+	// Critical requests skip the limit altogether
+	rel, err := l.Acquire(context.Background(), PriorityCritical)
+	if err != nil {
+		t.Fatalf("critical request = %v", err)
+	}
+	rel(0, false)
+}
+
+// A release admitting a waiter just as its queue timeout fires must hand the
+// slot over exactly once: either the waiter runs and releases it, or it is
+// shed and the slot is free again.
+func TestLimiterReleaseRacingTimeoutKeepsSlots(t *testing.T) {
+	l := newLimiter(Options{InitialLimit: 1, MaxLimit: 1, MaxQueue: 1, QueueTimeout: time.Millisecond})
+	for i := 0; i < 500; i++ {
+		hold, err := l.Acquire(context.Background(), PriorityNormal)
+		if err != nil {
+			t.Fatalf("iteration %d: slot leaked: %v", i, err)
+		}
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			if rel, err := l.Acquire(context.Background(), PriorityNormal); err == nil {
+				rel(0, false)
+			}
+		}()
+		time.Sleep(time.Duration(i%3) * 500 * time.Microsecond)
+		hold(0, false)
+		<-done
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.inflight != 0 || l.queue.Len() != 0 {
+		t.Fatalf("inflight = %d, queued = %d after all requests finished", l.inflight, l.queue.Len())
+	}
+}
+
+// waitQueued waits until n requests wait in the queue of l
+func waitQueued(t *testing.T, l *Limiter, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		l.mu.Lock()
+		queued := l.queue.Len()
+		l.mu.Unlock()
+		if queued >= n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d requests queued, want %d", queued, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
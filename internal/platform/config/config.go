@@ -2,6 +2,7 @@ package config
 
 import (
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -13,14 +14,143 @@ type Config struct {
 	Sentry    SentryConfig              `mapstructure:"sentry"`
 	Metrics   MetricsConfig             `mapstructure:"metrics"`
 	Logger    LoggerConfig              `mapstructure:"logger"`
+	Auth      AuthConfig                `mapstructure:"auth"`
+	Tenant    TenantConfig              `mapstructure:"tenant"`
+	Modules   map[string]ModuleConfig   `mapstructure:"modules"`
+	HTTPCache CacheConfig               `mapstructure:"http_cache"`
+	RateLimit RateLimitConfig           `mapstructure:"rate_limit"`
+	Admin     AdminConfig               `mapstructure:"admin"`
+	Redaction RedactionConfig           `mapstructure:"redaction"`
+	Tracing   TracingConfig             `mapstructure:"tracing"`
 }
 
 type ServerConfig struct {
-	Address string `mapstructure:"address"`
+	Address           string        `mapstructure:"address"`
+	ReadTimeout       time.Duration `mapstructure:"read_timeout"`
+	ReadHeaderTimeout time.Duration `mapstructure:"read_header_timeout"`
+	WriteTimeout      time.Duration `mapstructure:"write_timeout"`
+	IdleTimeout       time.Duration `mapstructure:"idle_timeout"`
+	// ShutdownTimeout is how long in-flight requests may finish on shutdown
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"`
+	MaxHeaderBytes  int           `mapstructure:"max_header_bytes"`
+	// MaxBodyBytes limits request bodies; zero disables the limit
+	MaxBodyBytes int64 `mapstructure:"max_body_bytes"`
+	// H2C serves HTTP/2 without TLS, for internal traffic behind a proxy
+	H2C bool `mapstructure:"h2c"`
+	// TrustedProxies are the addresses and CIDR ranges of proxies whose
+	// X-Forwarded-For names the client
+	TrustedProxies []string          `mapstructure:"trusted_proxies"`
+	TLS            TLSConfig         `mapstructure:"tls"`
+	Concurrency    ConcurrencyConfig `mapstructure:"concurrency"`
+}
+
+// TLSConfig serves HTTPS when CertFile and KeyFile are set; the files are reloaded when they change
+type TLSConfig struct {
+	CertFile string `mapstructure:"cert_file"`
+	KeyFile  string `mapstructure:"key_file"`
+	// ClientAuth is "none" (default), "verify" to verify client certificates
+	// that are sent, or "require" for mutual TLS
+	ClientAuth   string `mapstructure:"client_auth"`
+	ClientCAFile string `mapstructure:"client_ca_file"`
+	// Clients grants scopes to verified client certificates by subject
+	Clients []ClientCertConfig `mapstructure:"clients"`
+}
+
+// Enabled reports whether the server serves HTTPS
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != "" && c.KeyFile != ""
+}
+
+type ClientCertConfig struct {
+	// Subject is the certificate's first URI SAN, such as a SPIFFE ID, or else its common name
+	Subject string   `mapstructure:"subject"`
+	Scopes  []string `mapstructure:"scopes"`
+	Tenant  string   `mapstructure:"tenant"`
+}
+
+// ConcurrencyConfig tunes the adaptive limit of concurrent requests
+type ConcurrencyConfig struct {
+	Enabled      bool `mapstructure:"enabled"`
+	InitialLimit int  `mapstructure:"initial_limit"`
+	MinLimit     int  `mapstructure:"min_limit"`
+	MaxLimit     int  `mapstructure:"max_limit"`
+	// LatencyThreshold is the latency above which the limit backs off
+	LatencyThreshold time.Duration `mapstructure:"latency_threshold"`
+	// Backoff multiplies the limit when a request is slower than LatencyThreshold
+	// or fails with a 5xx status
+	Backoff float64 `mapstructure:"backoff"`
+	// MaxQueue is how many requests may wait for a slot before the lowest priority is shed
+	MaxQueue     int           `mapstructure:"max_queue"`
+	QueueTimeout time.Duration `mapstructure:"queue_timeout"`
+	// RetryAfter is sent with shed requests
+	RetryAfter time.Duration `mapstructure:"retry_after"`
+	// DefaultPriority is "low", "normal" (default), "high" or "critical" (never shed)
+	DefaultPriority string                `mapstructure:"default_priority"`
+	Routes          []RoutePriorityConfig `mapstructure:"routes"`
+}
+
+// RoutePriorityConfig sets the priority class of one route
+type RoutePriorityConfig struct {
+	// Method is the HTTP method; empty matches every method
+	Method   string `mapstructure:"method"`
+	Pattern  string `mapstructure:"pattern"`
+	Priority string `mapstructure:"priority"`
+}
+
+// databaseDefaults are the defaults of every database, keyed by setting
+var databaseDefaults = map[string]any{
+	"driver":                  "pgx",
+	"sticky_window":           "2s",
+	"replica_check_interval":  "10s",
+	"pool":                    "database/sql",
+	"max_open_conns":          25,
+	"conn_max_lifetime":       "30m",
+	"conn_max_idle_time":      "5m",
+	"application_name":        "hello-service",
+	"slow_query_threshold":    "200ms",
+	"connect.initial_backoff": "500ms",
+	"connect.max_backoff":     "15s",
+	"connect.timeout":         "1m",
 }
 
 type DatabaseConfig struct {
-	URI string `mapstructure:"uri"`
+	// Driver is "pgx" (default), "sqlite" or "memory" to run without a database
+	Driver string `mapstructure:"driver"`
+	URI    string `mapstructure:"uri"`
+	// Replicas are read-only DSNs reads are balanced across
+	Replicas []string `mapstructure:"replicas"`
+	// StickyWindow keeps a request's reads on the primary for this long after it writes
+	StickyWindow time.Duration `mapstructure:"sticky_window"`
+	// ReplicaCheckInterval is how often replica health is probed
+	ReplicaCheckInterval time.Duration `mapstructure:"replica_check_interval"`
+
+	// Pool is "database/sql" (default) or "pgxpool"
+	Pool         string `mapstructure:"pool"`
+	MaxOpenConns int    `mapstructure:"max_open_conns"`
+	// MaxIdleConns defaults to MaxOpenConns; pgxpool has no such limit and rejects it
+	MaxIdleConns    int           `mapstructure:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `mapstructure:"conn_max_idle_time"`
+	// StatementTimeout is sent as the statement_timeout of every connection
+	StatementTimeout time.Duration `mapstructure:"statement_timeout"`
+	ApplicationName  string        `mapstructure:"application_name"`
+	// SlowQueryThreshold logs queries taking at least this long; zero disables it
+	SlowQueryThreshold time.Duration `mapstructure:"slow_query_threshold"`
+
+	// Optional databases may be unreachable at startup; the service then runs degraded
+	Optional bool          `mapstructure:"optional"`
+	Connect  ConnectConfig `mapstructure:"connect"`
+
+	// AutoMigrate applies embedded migrations at startup
+	AutoMigrate bool `mapstructure:"auto_migrate"`
+}
+
+// ConnectConfig controls how long startup waits for a database
+type ConnectConfig struct {
+	InitialBackoff time.Duration `mapstructure:"initial_backoff"`
+	MaxBackoff     time.Duration `mapstructure:"max_backoff"`
+	// Timeout is the total deadline for reaching a required database
+	Timeout time.Duration `mapstructure:"timeout"`
 }
 
 type SentryConfig struct {
@@ -31,10 +161,188 @@ type SentryConfig struct {
 type MetricsConfig struct {
 	Enabled bool   `mapstructure:"enabled"`
 	Path    string `mapstructure:"path"`
+	// DurationBuckets are the buckets of HTTP request durations, in seconds
+	DurationBuckets []float64 `mapstructure:"duration_buckets"`
+	// SizeBuckets are the buckets of HTTP request and response sizes, in bytes
+	SizeBuckets []float64 `mapstructure:"size_buckets"`
+	// DatabaseBuckets are the buckets of database operation durations, in seconds
+	DatabaseBuckets []float64 `mapstructure:"database_buckets"`
 }
 
 type LoggerConfig struct {
 	Level string `mapstructure:"level"`
+	// Encoding is "json" or "console"
+	Encoding string `mapstructure:"encoding"`
+	// Color colors the levels of console output written to stdout or stderr
+	Color bool `mapstructure:"color"`
+	// Development logs human-friendly timestamps, stack traces from warnings
+	// on, and panics on DPanic
+	Development bool `mapstructure:"development"`
+	// Outputs are "stdout", "stderr", "file" or "syslog"
+	Outputs  []string          `mapstructure:"outputs"`
+	File     LogFileConfig     `mapstructure:"file"`
+	Syslog   SyslogConfig      `mapstructure:"syslog"`
+	Sampling LogSamplingConfig `mapstructure:"sampling"`
+	// DebugDuration is how long SIGUSR1 raises the level to debug before it reverts
+	DebugDuration time.Duration `mapstructure:"debug_duration"`
+	// DebugScopes are the scopes allowed to request debug logs with X-Debug-Log: 1
+	DebugScopes []string        `mapstructure:"debug_scopes"`
+	AccessLog   AccessLogConfig `mapstructure:"access_log"`
+}
+
+// LogFileConfig is the "file" output, rotated by size and time
+type LogFileConfig struct {
+	Path string `mapstructure:"path"`
+	// MaxSizeMB rotates the file once it grows past this size
+	MaxSizeMB int `mapstructure:"max_size_mb"`
+	// RotateEvery also rotates the file at every multiple of this interval; zero disables it
+	RotateEvery time.Duration `mapstructure:"rotate_every"`
+	// MaxAge removes rotated files older than this, rounded up to whole days; zero keeps them
+	MaxAge time.Duration `mapstructure:"max_age"`
+	// MaxBackups is how many rotated files are kept; zero keeps them all
+	MaxBackups int  `mapstructure:"max_backups"`
+	Compress   bool `mapstructure:"compress"`
+}
+
+// SyslogConfig is the "syslog" output; an empty network logs to the local daemon
+type SyslogConfig struct {
+	Network string `mapstructure:"network"`
+	Address string `mapstructure:"address"`
+	Tag     string `mapstructure:"tag"`
+}
+
+// LogSamplingConfig logs the first Initial entries with the same level and
+// message per Tick, then every Thereafter-th one
+type LogSamplingConfig struct {
+	Enabled    bool          `mapstructure:"enabled"`
+	Initial    int           `mapstructure:"initial"`
+	Thereafter int           `mapstructure:"thereafter"`
+	Tick       time.Duration `mapstructure:"tick"`
+}
+
+type AccessLogConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// SampleRate is the fraction of requests logged, from 0 to 1; 5xx responses are always logged
+	SampleRate float64 `mapstructure:"sample_rate"`
+	// Exclude lists request paths that are never logged
+	Exclude []string `mapstructure:"exclude"`
+}
+
+type TracingConfig struct {
+	ServiceName string `mapstructure:"service_name"`
+	// Exporter is "otlp", "stdout", "file" or "none"
+	Exporter string `mapstructure:"exporter"`
+	// SampleRatio is the fraction of new traces recorded; requests continuing
+	// a trace follow the caller's decision
+	SampleRatio float64    `mapstructure:"sample_ratio"`
+	OTLP        OTLPConfig `mapstructure:"otlp"`
+	// File is where the "file" exporter appends spans as JSON
+	File string `mapstructure:"file"`
+}
+
+// OTLPConfig is the collector the "otlp" exporter sends spans to over HTTP
+type OTLPConfig struct {
+	// Endpoint is a host:port, or a URL to also set the path
+	Endpoint string            `mapstructure:"endpoint"`
+	Insecure bool              `mapstructure:"insecure"`
+	Headers  map[string]string `mapstructure:"headers"`
+	Timeout  time.Duration     `mapstructure:"timeout"`
+}
+
+// RedactionConfig lists what is masked in logs and Sentry events
+type RedactionConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Mask replaces every redacted value
+	Mask string `mapstructure:"mask"`
+	// Headers are request header names whose values are masked
+	Headers []string `mapstructure:"headers"`
+	// QueryParams are URL query parameters whose values are masked
+	QueryParams []string `mapstructure:"query_params"`
+	// Fields are JSON fields and log fields whose values are masked
+	Fields []string `mapstructure:"fields"`
+	// Patterns name built-in patterns masked wherever they appear:
+	// "bearer", "jwt", "email" and "card"
+	Patterns []string `mapstructure:"patterns"`
+	// CustomPatterns are further regular expressions masked wherever they match
+	CustomPatterns []string `mapstructure:"custom_patterns"`
+}
+
+type AuthConfig struct {
+	APIKeys         []APIKeyConfig `mapstructure:"api_keys"`
+	AnonymousScopes []string       `mapstructure:"anonymous_scopes"`
+}
+
+type APIKeyConfig struct {
+	Key     string   `mapstructure:"key"`
+	Subject string   `mapstructure:"subject"`
+	Scopes  []string `mapstructure:"scopes"`
+	Tenant  string   `mapstructure:"tenant"`
+}
+
+type TenantConfig struct {
+	Header     string `mapstructure:"header"`
+	Claim      string `mapstructure:"claim"`
+	BaseDomain string `mapstructure:"base_domain"`
+	Default    string `mapstructure:"default"`
+	// SelectScopes let callers without a tenant on their credentials pick one
+	// by header or subdomain; everyone else is rejected when they try.
+	// Defaults to "tenant:select" and "admin".
+	SelectScopes []string `mapstructure:"select_scopes"`
+	// Databases maps tenant ids to the registry database holding their data
+	Databases map[string]string `mapstructure:"databases"`
+}
+
+type ModuleConfig struct {
+	// Database is the registry database the module reads and writes
+	Database string `mapstructure:"database"`
+	// Cache puts a read-through cache in front of the module's datastore
+	Cache CacheConfig `mapstructure:"cache"`
+}
+
+type CacheConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Backend is "memory" (default), an in-process LRU
+	Backend string `mapstructure:"backend"`
+	// Size is the maximum number of entries of the memory backend
+	Size int           `mapstructure:"size"`
+	TTL  time.Duration `mapstructure:"ttl"`
+}
+
+type RateLimitConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Store is "memory" (default, per replica) or "postgres" to share limits across replicas
+	Store string `mapstructure:"store"`
+	// Database is the registry database of the postgres store
+	Database string `mapstructure:"database"`
+	// Rate is the sustained number of requests per second a client may make
+	Rate float64 `mapstructure:"rate"`
+	// Burst is how many requests a client may make at once
+	Burst  int                    `mapstructure:"burst"`
+	Routes []RouteRateLimitConfig `mapstructure:"routes"`
+	// IPRate and IPBurst limit each client IP before authentication, which
+	// also throttles guessing API keys; a zero IPBurst disables it
+	IPRate  float64 `mapstructure:"ip_rate"`
+	IPBurst int     `mapstructure:"ip_burst"`
+}
+
+// RouteRateLimitConfig overrides the limit of one route; clients get a separate bucket for it
+type RouteRateLimitConfig struct {
+	// Method is the HTTP method; empty matches every method
+	Method string `mapstructure:"method"`
+	// Pattern is the chi route pattern, such as "/api/v1/hello/{id}"
+	Pattern string  `mapstructure:"pattern"`
+	Rate    float64 `mapstructure:"rate"`
+	Burst   int     `mapstructure:"burst"`
+}
+
+// AdminConfig moves metrics, pprof, probes and the admin endpoints to their own listener
+type AdminConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Address string `mapstructure:"address"`
+	// Auth is "none", "token" (a Bearer Token) or "api_key" (an auth API key holding the admin scope).
+	// Health and readiness probes never require it.
+	Auth  string `mapstructure:"auth"`
+	Token string `mapstructure:"token"`
 }
 
 func Load() *Config {
@@ -55,10 +363,79 @@ func Load() *Config {
 
 	// Defaults
 	viper.SetDefault("server.address", ":8080")
+	viper.SetDefault("server.read_timeout", "15s")
+	viper.SetDefault("server.read_header_timeout", "5s")
+	viper.SetDefault("server.write_timeout", "15s")
+	viper.SetDefault("server.idle_timeout", "60s")
+	viper.SetDefault("server.shutdown_timeout", "10s")
+	viper.SetDefault("server.max_header_bytes", 1<<20)
+	viper.SetDefault("server.max_body_bytes", 1<<20)
+	viper.SetDefault("server.tls.client_auth", "none")
+	viper.SetDefault("server.concurrency.initial_limit", 100)
+	viper.SetDefault("server.concurrency.min_limit", 10)
+	viper.SetDefault("server.concurrency.max_limit", 1000)
+	viper.SetDefault("server.concurrency.latency_threshold", "1s")
+	viper.SetDefault("server.concurrency.backoff", 0.9)
+	viper.SetDefault("server.concurrency.max_queue", 100)
+	viper.SetDefault("server.concurrency.queue_timeout", "100ms")
+	viper.SetDefault("server.concurrency.retry_after", "1s")
+	viper.SetDefault("server.concurrency.default_priority", "normal")
 	viper.SetDefault("sentry.environment", "development")
 	viper.SetDefault("metrics.enabled", true)
 	viper.SetDefault("metrics.path", "/metrics")
 	viper.SetDefault("logger.level", "info")
+	viper.SetDefault("logger.encoding", "json")
+	viper.SetDefault("logger.color", true)
+	viper.SetDefault("logger.outputs", []string{"stderr"})
+	viper.SetDefault("logger.file.path", "logs/app.log")
+	viper.SetDefault("logger.file.max_size_mb", 100)
+	viper.SetDefault("logger.file.rotate_every", "24h")
+	viper.SetDefault("logger.file.max_age", "168h")
+	viper.SetDefault("logger.file.max_backups", 10)
+	viper.SetDefault("logger.syslog.tag", "hello")
+	viper.SetDefault("logger.sampling.enabled", true)
+	viper.SetDefault("logger.sampling.initial", 100)
+	viper.SetDefault("logger.sampling.thereafter", 100)
+	viper.SetDefault("logger.sampling.tick", "1s")
+	viper.SetDefault("logger.debug_duration", "5m")
+	viper.SetDefault("logger.debug_scopes", []string{"admin"})
+	viper.SetDefault("tracing.service_name", "hello")
+	viper.SetDefault("tracing.exporter", "none")
+	viper.SetDefault("tracing.sample_ratio", 1.0)
+	viper.SetDefault("tracing.otlp.endpoint", "localhost:4318")
+	viper.SetDefault("tracing.otlp.insecure", true)
+	viper.SetDefault("tracing.otlp.timeout", "10s")
+	viper.SetDefault("tracing.file", "logs/traces.jsonl")
+	viper.SetDefault("redaction.enabled", true)
+	viper.SetDefault("redaction.mask", "[REDACTED]")
+	viper.SetDefault("redaction.headers", []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-API-Key"})
+	viper.SetDefault("redaction.query_params", []string{"token", "access_token", "api_key", "key", "password", "secret"})
+	viper.SetDefault("redaction.fields", []string{"password", "secret", "token", "access_token", "refresh_token", "api_key", "authorization", "cookie"})
+	viper.SetDefault("redaction.patterns", []string{"bearer", "jwt", "email", "card"})
+	viper.SetDefault("logger.access_log.enabled", true)
+	viper.SetDefault("logger.access_log.sample_rate", 1.0)
+	viper.SetDefault("logger.access_log.exclude", []string{"/health", "/livez", "/readyz", "/metrics"})
+	viper.SetDefault("auth.anonymous_scopes", []string{"hello:read"})
+	viper.SetDefault("tenant.header", "X-Tenant-ID")
+	viper.SetDefault("tenant.claim", "tenant_id")
+	viper.SetDefault("tenant.default", "default")
+	viper.SetDefault("tenant.select_scopes", []string{"tenant:select", "admin"})
+	viper.SetDefault("admin.address", ":9090")
+	viper.SetDefault("admin.auth", "token")
+	viper.SetDefault("rate_limit.store", "memory")
+	viper.SetDefault("rate_limit.database", "main")
+	viper.SetDefault("rate_limit.rate", 10)
+	viper.SetDefault("rate_limit.burst", 20)
+	viper.SetDefault("rate_limit.ip_rate", 50)
+	viper.SetDefault("rate_limit.ip_burst", 100)
+
+	// database.* and every databases.<name> entry share their defaults
+	for key, value := range databaseDefaults {
+		viper.SetDefault("database."+key, value)
+		for name := range viper.GetStringMap("databases") {
+			viper.SetDefault("databases."+name+"."+key, value)
+		}
+	}
 
 	var config Config
 	if err := viper.Unmarshal(&config); err != nil {
@@ -68,7 +445,7 @@ func Load() *Config {
 	// Backward compatibility: if Databases is empty but Database.URI provided, seed "main"
 	if len(config.Databases) == 0 && config.Database.URI != "" {
 		config.Databases = map[string]DatabaseConfig{
-			"main": {URI: config.Database.URI},
+			"main": config.Database,
 		}
 	}
 
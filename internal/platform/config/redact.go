@@ -0,0 +1,79 @@
+package config
+
+import (
+	"net/url"
+	"regexp"
+)
+
+const redacted = "REDACTED"
+
+// keywordPassword matches the password of a keyword/value DSN such as "user=x password=y"
+var keywordPassword = regexp.MustCompile(`(?i)(password\s*=\s*)('(?:[^'\\]|\\.)*'|\S+)`)
+
+// Redacted returns a copy of c with passwords, keys and tokens masked, safe to show to operators
+func (c Config) Redacted() Config {
+	c.Database = c.Database.redacted()
+	if c.Databases != nil {
+		dbs := make(map[string]DatabaseConfig, len(c.Databases))
+		for name, dc := range c.Databases {
+			dbs[name] = dc.redacted()
+		}
+		c.Databases = dbs
+	}
+	if c.Sentry.DSN != "" {
+		c.Sentry.DSN = redactURL(c.Sentry.DSN)
+	}
+	keys := make([]APIKeyConfig, len(c.Auth.APIKeys))
+	for i, k := range c.Auth.APIKeys {
+		k.Key = redacted
+		keys[i] = k
+	}
+	c.Auth.APIKeys = keys
+	if c.Admin.Token != "" {
+		c.Admin.Token = redacted
+	}
+	if c.Tracing.OTLP.Headers != nil {
+		// Collector headers usually carry an auth token
+		headers := make(map[string]string, len(c.Tracing.OTLP.Headers))
+		for k := range c.Tracing.OTLP.Headers {
+			headers[k] = redacted
+		}
+		c.Tracing.OTLP.Headers = headers
+	}
+	return c
+}
+
+func (dc DatabaseConfig) redacted() DatabaseConfig {
+	dc.URI = redactDSN(dc.URI)
+	replicas := make([]string, len(dc.Replicas))
+	for i, r := range dc.Replicas {
+		replicas[i] = redactDSN(r)
+	}
+	dc.Replicas = replicas
+	return dc
+}
+
+// redactDSN masks the password of a URL or keyword/value DSN
+func redactDSN(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.Scheme != "" && u.User != nil {
+		return redactURL(dsn)
+	}
+	return keywordPassword.ReplaceAllString(dsn, "${1}"+redacted)
+}
+
+// redactURL masks the user info secret of a URL, such as a Sentry DSN key
+func redactURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil {
+		return redacted
+	}
+	if u.User == nil {
+		return s
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), redacted)
+	} else {
+		u.User = url.User(redacted)
+	}
+	return u.String()
+}
@@ -0,0 +1,131 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"strings"
+	"time"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/Jexim/HelloGo/internal/platform/logger"
+	"github.com/Jexim/HelloGo/internal/platform/metrics"
+	"github.com/Jexim/HelloGo/internal/platform/tracing"
+)
+
+// DBTX is the interface sqlc generated queries run against
+type DBTX interface {
+	ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
+	PrepareContext(context.Context, string) (*sql.Stmt, error)
+	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
+	QueryRowContext(context.Context, string, ...interface{}) *sql.Row
+}
+
+var queryNamePattern = regexp.MustCompile(`--\s*name:\s*(\w+)`)
+
+// Instrumenter wraps DBTX values with metrics, spans and slow-query logging
+type Instrumenter struct {
+	metrics *metrics.Metrics
+	logger  *zap.Logger
+	slow    time.Duration
+}
+
+// NewInstrumenter records to m and logs queries slower than slow; zero
+// disables slow-query logging
+func NewInstrumenter(m *metrics.Metrics, logger *zap.Logger, slow time.Duration) *Instrumenter {
+	return &Instrumenter{metrics: m, logger: logger, slow: slow}
+}
+
+// Wrap returns db instrumented. Every Exec, Query and QueryRow records
+// the DatabaseOperations and DatabaseOperationDuration metrics under the
+// name of sqlc's "-- name:" comment and runs in a client span of that name.
+// Prepare is recorded as "prepare:" and that name; the returned statement
+// runs outside the instrumentation.
+func (i *Instrumenter) Wrap(db DBTX) DBTX {
+	return &instrumentedDB{db: db, i: i}
+}
+
+type instrumentedDB struct {
+	db DBTX
+	i  *Instrumenter
+}
+
+func (d *instrumentedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	done := d.i.start(ctx, OperationName(query))
+	res, err := d.db.ExecContext(ctx, query, args...)
+	done(err)
+	return res, err
+}
+
+func (d *instrumentedDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	done := d.i.start(ctx, "prepare:"+OperationName(query))
+	stmt, err := d.db.PrepareContext(ctx, query)
+	done(err)
+	return stmt, err
+}
+
+func (d *instrumentedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	done := d.i.start(ctx, OperationName(query))
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	done(err)
+	return rows, err
+}
+
+func (d *instrumentedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	done := d.i.start(ctx, OperationName(query))
+	row := d.db.QueryRowContext(ctx, query, args...)
+	// Err reports query failures but not sql.ErrNoRows, which is a result, not an error
+	done(row.Err())
+	return row
+}
+
+// start opens the span of operation op and returns the function that records its outcome
+func (i *Instrumenter) start(ctx context.Context, op string) func(err error) {
+	_, span := tracing.Start(ctx, op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBOperationName(op)),
+	)
+	start := time.Now()
+	return func(err error) {
+		elapsed := time.Since(start)
+		tracing.End(span, err)
+		status := "ok"
+		if err != nil {
+			status = "error"
+		}
+
+		i.metrics.DatabaseOperations.WithLabelValues(op, status).Inc()
+		metrics.Observe(ctx, i.metrics.DatabaseOperationDuration.WithLabelValues(op), elapsed.Seconds())
+
+		// Visible at debug level, or for requests asking for debug logs
+		logger.For(ctx, i.logger).Debug("query",
+			zap.String("operation", op),
+			zap.Duration("duration", elapsed),
+			zap.String("status", status),
+			zap.String("trace_id", tracing.TraceID(ctx)),
+		)
+
+		if i.slow > 0 && elapsed >= i.slow {
+			i.logger.Warn("slow_query",
+				zap.String("operation", op),
+				zap.Duration("duration", elapsed),
+				zap.String("status", status),
+				zap.String("trace_id", tracing.TraceID(ctx)),
+			)
+		}
+	}
+}
+
+// OperationName returns the sqlc query name of query, or its lowercased first keyword
+func OperationName(query string) string {
+	if m := queryNamePattern.FindStringSubmatch(query); m != nil {
+		return m[1]
+	}
+	if f := strings.Fields(query); len(f) > 0 {
+		return strings.ToLower(f[0])
+	}
+	return "unknown"
+}
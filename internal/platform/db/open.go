@@ -0,0 +1,127 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	_ "modernc.org/sqlite"
+
+	"github.com/Jexim/HelloGo/internal/platform/config"
+)
+
+const (
+	// DriverPgx connects to Postgres through pgx
+	DriverPgx = "pgx"
+	// DriverSQLite opens a pure-Go SQLite database; URI is its file name or DSN
+	DriverSQLite = "sqlite"
+	// DriverMemory needs no database; datastores keep their data in process
+	DriverMemory = "memory"
+)
+
+const (
+	// PoolDatabaseSQL keeps connections in the database/sql pool
+	PoolDatabaseSQL = "database/sql"
+	// PoolPgx keeps connections in a pgxpool.Pool and exposes it for native pgx use such as COPY
+	PoolPgx = "pgxpool"
+)
+
+// conn is one pool of the registry; pool is set in pgxpool mode
+type conn struct {
+	name    string
+	driver  string
+	db      *sql.DB
+	pool    *pgxpool.Pool
+	healthy atomic.Bool
+}
+
+func (c *conn) close() error {
+	err := c.db.Close()
+	if c.pool != nil {
+		c.pool.Close()
+	}
+	return err
+}
+
+// open creates a pool for dsn tuned by dc without connecting
+func open(name, dsn string, dc config.DatabaseConfig) (*conn, error) {
+	switch dc.Driver {
+	case "", DriverPgx:
+		return openPgx(name, dsn, dc)
+	case DriverSQLite:
+		db, err := sql.Open("sqlite", dsn)
+		if err != nil {
+			return nil, err
+		}
+		// SQLite allows a single writer; one connection serializes access instead of
+		// failing with SQLITE_BUSY, and keeps ":memory:" databases to a single instance
+		db.SetMaxOpenConns(1)
+		db.SetConnMaxLifetime(0)
+		db.SetConnMaxIdleTime(0)
+		return &conn{name: name, driver: DriverSQLite, db: db}, nil
+	default:
+		return nil, fmt.Errorf("unknown driver %q", dc.Driver)
+	}
+}
+
+func openPgx(name, dsn string, dc config.DatabaseConfig) (*conn, error) {
+	switch dc.Pool {
+	case "", PoolDatabaseSQL:
+		cc, err := pgx.ParseConfig(dsn)
+		if err != nil {
+			return nil, err
+		}
+		applyRuntimeParams(cc.RuntimeParams, dc)
+		db := stdlib.OpenDB(*cc)
+		db.SetMaxOpenConns(dc.MaxOpenConns)
+		idle := dc.MaxIdleConns
+		if idle <= 0 {
+			idle = dc.MaxOpenConns
+		}
+		db.SetMaxIdleConns(idle)
+		db.SetConnMaxLifetime(dc.ConnMaxLifetime)
+		db.SetConnMaxIdleTime(dc.ConnMaxIdleTime)
+		return &conn{name: name, driver: DriverPgx, db: db}, nil
+	case PoolPgx:
+		// pgxpool closes idle connections by age only, so an idle limit cannot be honoured
+		if dc.MaxIdleConns > 0 {
+			return nil, fmt.Errorf("max_idle_conns is not supported by the %s pool", PoolPgx)
+		}
+		pc, err := pgxpool.ParseConfig(dsn)
+		if err != nil {
+			return nil, err
+		}
+		applyRuntimeParams(pc.ConnConfig.RuntimeParams, dc)
+		if dc.MaxOpenConns > 0 {
+			pc.MaxConns = int32(dc.MaxOpenConns)
+		}
+		if dc.ConnMaxLifetime > 0 {
+			pc.MaxConnLifetime = dc.ConnMaxLifetime
+		}
+		if dc.ConnMaxIdleTime > 0 {
+			pc.MaxConnIdleTime = dc.ConnMaxIdleTime
+		}
+		pool, err := pgxpool.NewWithConfig(context.Background(), pc)
+		if err != nil {
+			return nil, err
+		}
+		return &conn{name: name, driver: DriverPgx, db: stdlib.OpenDBFromPool(pool), pool: pool}, nil
+	default:
+		return nil, fmt.Errorf("unknown pool %q", dc.Pool)
+	}
+}
+
+// applyRuntimeParams sets the per-connection server settings configured in dc
+func applyRuntimeParams(params map[string]string, dc config.DatabaseConfig) {
+	if dc.ApplicationName != "" {
+		params["application_name"] = dc.ApplicationName
+	}
+	if dc.StatementTimeout > 0 {
+		params["statement_timeout"] = strconv.FormatInt(dc.StatementTimeout.Milliseconds(), 10)
+	}
+}
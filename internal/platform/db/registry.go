@@ -4,44 +4,236 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"sort"
+	"sync/atomic"
 	"time"
 
-	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+
+	"github.com/Jexim/HelloGo/internal/platform/config"
 )
 
 type Registry struct {
-	conns map[string]*sql.DB
+	conns  map[string]*cluster
+	logger *zap.Logger
+}
+
+// cluster is a primary with its read replicas
+type cluster struct {
+	primary       *conn
+	replicas      []*conn
+	next          atomic.Uint64
+	optional      bool
+	stickyWindow  time.Duration
+	checkInterval time.Duration
+	backoff       backoff
 }
 
-// OpenAll opens connections for provided DSNs keyed by name
-func OpenAll(dbs map[string]string) (*Registry, error) {
-	reg := &Registry{conns: make(map[string]*sql.DB)}
-	for name, dsn := range dbs {
-		conn, err := sql.Open("pgx", dsn)
+// Entry is a single connection pool of the registry, either a primary or one of its replicas
+type Entry struct {
+	Name   string
+	Driver string
+	DB     *sql.DB
+	// Pool is the underlying pgxpool.Pool in pgxpool mode, nil otherwise
+	Pool *pgxpool.Pool
+	// Optional is set when the service may run without this pool: the primary
+	// of an optional database, and every replica, as reads fall back to the primary
+	Optional bool
+	// Healthy is the result of the last connection attempt or background probe
+	Healthy bool
+}
+
+// OpenAll opens connections for provided databases keyed by name.
+// Required primaries are retried with exponential backoff and jitter until their
+// connect timeout or the deadline of ctx passes, which fails the call; callers
+// bound the startup of all databases together with that deadline. Optional primaries and replicas are
+// tried once and kept even when unreachable, so the service can start degraded
+// while Monitor re-establishes them in the background. Memory databases are skipped.
+func OpenAll(ctx context.Context, dbs map[string]config.DatabaseConfig, logger *zap.Logger) (*Registry, error) {
+	reg := &Registry{conns: make(map[string]*cluster), logger: logger}
+	for name, dc := range dbs {
+		if dc.Driver == DriverMemory {
+			continue
+		}
+		primary, err := open(name, dc.URI, dc)
 		if err != nil {
+			_ = reg.Close()
 			return nil, fmt.Errorf("open db %s: %w", name, err)
 		}
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		if err := conn.PingContext(ctx); err != nil {
-			cancel()
-			_ = conn.Close()
+		c := &cluster{
+			primary:       primary,
+			optional:      dc.Optional,
+			stickyWindow:  dc.StickyWindow,
+			checkInterval: dc.ReplicaCheckInterval,
+			backoff:       newBackoff(dc.Connect),
+		}
+		if c.checkInterval <= 0 {
+			c.checkInterval = 10 * time.Second
+		}
+		reg.conns[name] = c
+
+		if dc.Optional {
+			err = ping(ctx, primary.db)
+		} else {
+			err = connect(ctx, primary.db, c.backoff, func(attempt int, err error) {
+				logger.Warn("database not ready, retrying", zap.String("database", name), zap.Int("attempt", attempt+1), zap.Error(err))
+			})
+		}
+		switch {
+		case err == nil:
+			primary.healthy.Store(true)
+		case dc.Optional:
+			logger.Warn("optional database unavailable, starting degraded", zap.String("database", name), zap.Error(err))
+		default:
+			_ = reg.Close()
 			return nil, fmt.Errorf("ping db %s: %w", name, err)
 		}
-		cancel()
-		reg.conns[name] = conn
+
+		for i, dsn := range dc.Replicas {
+			rc, err := open(fmt.Sprintf("%s/replica-%d", name, i), dsn, dc)
+			if err != nil {
+				_ = reg.Close()
+				return nil, fmt.Errorf("open db %s replica %d: %w", name, i, err)
+			}
+			rc.healthy.Store(ping(ctx, rc.db) == nil)
+			c.replicas = append(c.replicas, rc)
+		}
 	}
 	return reg, nil
 }
 
+// Get returns the primary of the named database
 func (r *Registry) Get(name string) *sql.DB {
-	return r.conns[name]
+	if c, ok := r.conns[name]; ok {
+		return c.primary.db
+	}
+	return nil
+}
+
+// Driver returns the driver of the named database, or an empty string if it is not registered
+func (r *Registry) Driver(name string) string {
+	if c, ok := r.conns[name]; ok {
+		return c.primary.driver
+	}
+	return ""
+}
+
+// Pool returns the pgxpool.Pool behind the primary of the named database,
+// or nil unless it runs in pgxpool mode
+func (r *Registry) Pool(name string) *pgxpool.Pool {
+	if c, ok := r.conns[name]; ok {
+		return c.primary.pool
+	}
+	return nil
+}
+
+// Primary returns the pool writes to the named database go to
+func (r *Registry) Primary(name string) *sql.DB {
+	return r.Get(name)
+}
+
+// Replica returns a healthy replica of the named database in round-robin order,
+// or its primary when it has no healthy replica
+func (r *Registry) Replica(name string) *sql.DB {
+	c, ok := r.conns[name]
+	if !ok {
+		return nil
+	}
+	n := len(c.replicas)
+	start := c.next.Add(1)
+	for i := 0; i < n; i++ {
+		rep := c.replicas[(start+uint64(i))%uint64(n)]
+		if rep.healthy.Load() {
+			return rep.db
+		}
+	}
+	return c.primary.db
+}
+
+// StickyWindow returns how long reads of the named database stay on the primary after a write
+func (r *Registry) StickyWindow(name string) time.Duration {
+	if c, ok := r.conns[name]; ok {
+		return c.stickyWindow
+	}
+	return 0
+}
+
+// Monitor probes every primary and replica in the background until ctx is done.
+// Healthy pools are pinged each check interval; lost ones are retried with backoff
+// until they answer again, so reads fail over and come back without a restart.
+func (r *Registry) Monitor(ctx context.Context) {
+	for _, c := range r.conns {
+		go r.monitor(ctx, c, c.primary)
+		for _, rep := range c.replicas {
+			go r.monitor(ctx, c, rep)
+		}
+	}
+}
+
+func (r *Registry) monitor(ctx context.Context, c *cluster, cn *conn) {
+	attempt := 0
+	for {
+		wait := c.checkInterval
+		if !cn.healthy.Load() {
+			wait = c.backoff.delay(attempt)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+
+		err := ping(ctx, cn.db)
+		switch was := cn.healthy.Swap(err == nil); {
+		case err == nil && !was:
+			r.logger.Info("database connection re-established", zap.String("database", cn.name))
+			attempt = 0
+		case err != nil && was:
+			r.logger.Warn("database connection lost", zap.String("database", cn.name), zap.Error(err))
+		case err != nil:
+			attempt++
+		}
+	}
+}
+
+// Names returns the registered database names in sorted order
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.conns))
+	for name := range r.conns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Entries returns every primary and replica pool, replicas named "<name>/replica-<n>".
+// Replicas are always optional.
+func (r *Registry) Entries() []Entry {
+	var entries []Entry
+	for _, name := range r.Names() {
+		c := r.conns[name]
+		for _, cn := range append([]*conn{c.primary}, c.replicas...) {
+			entries = append(entries, Entry{
+				Name:     cn.name,
+				Driver:   cn.driver,
+				DB:       cn.db,
+				Pool:     cn.pool,
+				Optional: c.optional || cn != c.primary,
+				Healthy:  cn.healthy.Load(),
+			})
+		}
+	}
+	return entries
 }
 
 func (r *Registry) Close() error {
 	var firstErr error
 	for _, c := range r.conns {
-		if err := c.Close(); err != nil && firstErr == nil {
-			firstErr = err
+		for _, cn := range append([]*conn{c.primary}, c.replicas...) {
+			if err := cn.close(); err != nil && firstErr == nil {
+				firstErr = err
+			}
 		}
 	}
 	return firstErr
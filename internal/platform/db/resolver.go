@@ -0,0 +1,148 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/Jexim/HelloGo/internal/platform/tenant"
+)
+
+const DefaultName = "main"
+
+// Source hands out the connection pool a request should use
+type Source interface {
+	// DB returns the primary, used for writes
+	DB(ctx context.Context) (*sql.DB, error)
+	// ReadDB returns a replica unless ctx must read its own writes
+	ReadDB(ctx context.Context) (*sql.DB, error)
+	// ReadTx runs fn in a read-only tenant transaction on ReadDB
+	ReadTx(ctx context.Context, fn func(db DBTX) error) error
+	// WriteTx runs fn in a tenant transaction on DB and makes ctx read its own writes
+	WriteTx(ctx context.Context, fn func(db DBTX) error) error
+}
+
+// Routing maps tenants and modules to named registry databases
+type Routing struct {
+	// Tenants maps a tenant id to the database holding its data
+	Tenants map[string]string
+	// Modules maps a module name to its database
+	Modules map[string]string
+}
+
+// Resolver picks registry databases per request.
+// A tenant mapping wins over a module mapping, which wins over DefaultName,
+// so large customers can live on dedicated instances.
+type Resolver struct {
+	reg     *Registry
+	routing Routing
+	ins     *Instrumenter
+}
+
+// NewResolver validates routing against reg; transactions handed out are instrumented by ins
+func NewResolver(reg *Registry, routing Routing, ins *Instrumenter) (*Resolver, error) {
+	for t, name := range routing.Tenants {
+		if reg.Get(name) == nil {
+			return nil, fmt.Errorf("tenant %s: database %s is not configured", t, name)
+		}
+	}
+	for m, name := range routing.Modules {
+		if reg.Get(name) == nil {
+			return nil, fmt.Errorf("module %s: database %s is not configured", m, name)
+		}
+	}
+	// Datastores are picked per driver, so a tenant must not move a module to another driver
+	for t, name := range routing.Tenants {
+		for _, m := range append([]string{DefaultName}, mapValues(routing.Modules)...) {
+			if reg.Driver(name) != reg.Driver(m) {
+				return nil, fmt.Errorf("tenant %s: database %s uses driver %s, but %s uses %s", t, name, reg.Driver(name), m, reg.Driver(m))
+			}
+		}
+	}
+	return &Resolver{reg: reg, routing: routing, ins: ins}, nil
+}
+
+// For returns the Source used by module
+func (r *Resolver) For(module string) Source {
+	return &moduleSource{r: r, module: module}
+}
+
+// Driver returns the driver of the database module is routed to
+func (r *Resolver) Driver(module string) string {
+	return r.reg.Driver(r.Name(context.Background(), module))
+}
+
+// Name returns the registry database name for module and the tenant in ctx
+func (r *Resolver) Name(ctx context.Context, module string) string {
+	if t := tenant.FromContext(ctx); t != "" {
+		if name, ok := r.routing.Tenants[strings.ToLower(t)]; ok {
+			return name
+		}
+	}
+	if name, ok := r.routing.Modules[module]; ok {
+		return name
+	}
+	return DefaultName
+}
+
+type moduleSource struct {
+	r      *Resolver
+	module string
+}
+
+func (s *moduleSource) DB(ctx context.Context) (*sql.DB, error) {
+	name := s.r.Name(ctx, s.module)
+	db := s.r.reg.Primary(name)
+	if db == nil {
+		return nil, fmt.Errorf("database %s is not configured", name)
+	}
+	return db, nil
+}
+
+func (s *moduleSource) ReadDB(ctx context.Context) (*sql.DB, error) {
+	name := s.r.Name(ctx, s.module)
+	if readFromPrimary(ctx, s.r.reg.StickyWindow(name)) {
+		return s.DB(ctx)
+	}
+	db := s.r.reg.Replica(name)
+	if db == nil {
+		return nil, fmt.Errorf("database %s is not configured", name)
+	}
+	return db, nil
+}
+
+var readOnly = &sql.TxOptions{ReadOnly: true}
+
+func (s *moduleSource) ReadTx(ctx context.Context, fn func(db DBTX) error) error {
+	db, err := s.ReadDB(ctx)
+	if err != nil {
+		return err
+	}
+	return s.tx(ctx, db, readOnly, fn)
+}
+
+func (s *moduleSource) WriteTx(ctx context.Context, fn func(db DBTX) error) error {
+	db, err := s.DB(ctx)
+	if err != nil {
+		return err
+	}
+	defer MarkWrite(ctx)
+	return s.tx(ctx, db, nil, fn)
+}
+
+// tx opens an instrumented tenant transaction; only Postgres enforces the tenant with RLS
+func (s *moduleSource) tx(ctx context.Context, db *sql.DB, opts *sql.TxOptions, fn func(db DBTX) error) error {
+	rls := s.r.reg.Driver(s.r.Name(ctx, s.module)) == DriverPgx
+	return tenantTx(ctx, db, opts, rls, func(tx *sql.Tx) error {
+		return fn(s.r.ins.Wrap(tx))
+	})
+}
+
+func mapValues(m map[string]string) []string {
+	values := make([]string, 0, len(m))
+	for _, v := range m {
+		values = append(values, v)
+	}
+	return values
+}
@@ -0,0 +1,66 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"math/rand/v2"
+	"time"
+
+	"github.com/Jexim/HelloGo/internal/platform/config"
+)
+
+// backoff computes exponential delays with full jitter
+type backoff struct {
+	initial time.Duration
+	max     time.Duration
+	timeout time.Duration
+}
+
+func newBackoff(cc config.ConnectConfig) backoff {
+	b := backoff{initial: cc.InitialBackoff, max: cc.MaxBackoff, timeout: cc.Timeout}
+	if b.initial <= 0 {
+		b.initial = 500 * time.Millisecond
+	}
+	if b.max <= 0 {
+		b.max = 15 * time.Second
+	}
+	if b.timeout <= 0 {
+		b.timeout = time.Minute
+	}
+	return b
+}
+
+// delay returns the wait before retry attempt n (0-based)
+func (b backoff) delay(n int) time.Duration {
+	d := b.initial << min(n, 30)
+	if d <= 0 || d > b.max {
+		d = b.max
+	}
+	return time.Duration(rand.Int64N(int64(d)) + 1)
+}
+
+// connect pings db until it answers, the backoff timeout passes or ctx is done
+func connect(ctx context.Context, db *sql.DB, b backoff, onRetry func(attempt int, err error)) error {
+	ctx, cancel := context.WithTimeout(ctx, b.timeout)
+	defer cancel()
+	for attempt := 0; ; attempt++ {
+		err := ping(ctx, db)
+		if err == nil {
+			return nil
+		}
+		if onRetry != nil {
+			onRetry(attempt, err)
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(b.delay(attempt)):
+		}
+	}
+}
+
+func ping(ctx context.Context, db *sql.DB) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	return db.PingContext(ctx)
+}
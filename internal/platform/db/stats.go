@@ -0,0 +1,55 @@
+package db
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	poolMaxOpenDesc = prometheus.NewDesc("db_pool_max_open_connections", "Maximum number of open connections to the database", []string{"database"}, nil)
+	poolOpenDesc    = prometheus.NewDesc("db_pool_open_connections", "Number of established connections, in use and idle", []string{"database"}, nil)
+	poolInUseDesc   = prometheus.NewDesc("db_pool_in_use_connections", "Number of connections currently in use", []string{"database"}, nil)
+	poolIdleDesc    = prometheus.NewDesc("db_pool_idle_connections", "Number of idle connections", []string{"database"}, nil)
+	poolWaitsDesc   = prometheus.NewDesc("db_pool_wait_count_total", "Total number of connections waited for", []string{"database"}, nil)
+	poolWaitDesc    = prometheus.NewDesc("db_pool_wait_duration_seconds_total", "Total time spent waiting for a connection", []string{"database"}, nil)
+)
+
+// StatsCollector exports the pool statistics of every registry entry
+type StatsCollector struct {
+	reg *Registry
+}
+
+func NewStatsCollector(reg *Registry) *StatsCollector {
+	return &StatsCollector{reg: reg}
+}
+
+func (c *StatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- poolMaxOpenDesc
+	ch <- poolOpenDesc
+	ch <- poolInUseDesc
+	ch <- poolIdleDesc
+	ch <- poolWaitsDesc
+	ch <- poolWaitDesc
+}
+
+func (c *StatsCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, e := range c.reg.Entries() {
+		if e.Pool != nil {
+			// database/sql keeps no idle connections on top of a pgxpool, so report the pool itself
+			s := e.Pool.Stat()
+			ch <- prometheus.MustNewConstMetric(poolMaxOpenDesc, prometheus.GaugeValue, float64(s.MaxConns()), e.Name)
+			ch <- prometheus.MustNewConstMetric(poolOpenDesc, prometheus.GaugeValue, float64(s.TotalConns()), e.Name)
+			ch <- prometheus.MustNewConstMetric(poolInUseDesc, prometheus.GaugeValue, float64(s.AcquiredConns()), e.Name)
+			ch <- prometheus.MustNewConstMetric(poolIdleDesc, prometheus.GaugeValue, float64(s.IdleConns()), e.Name)
+			ch <- prometheus.MustNewConstMetric(poolWaitsDesc, prometheus.CounterValue, float64(s.EmptyAcquireCount()), e.Name)
+			ch <- prometheus.MustNewConstMetric(poolWaitDesc, prometheus.CounterValue, s.AcquireDuration().Seconds(), e.Name)
+			continue
+		}
+		s := e.DB.Stats()
+		ch <- prometheus.MustNewConstMetric(poolMaxOpenDesc, prometheus.GaugeValue, float64(s.MaxOpenConnections), e.Name)
+		ch <- prometheus.MustNewConstMetric(poolOpenDesc, prometheus.GaugeValue, float64(s.OpenConnections), e.Name)
+		ch <- prometheus.MustNewConstMetric(poolInUseDesc, prometheus.GaugeValue, float64(s.InUse), e.Name)
+		ch <- prometheus.MustNewConstMetric(poolIdleDesc, prometheus.GaugeValue, float64(s.Idle), e.Name)
+		ch <- prometheus.MustNewConstMetric(poolWaitsDesc, prometheus.CounterValue, float64(s.WaitCount), e.Name)
+		ch <- prometheus.MustNewConstMetric(poolWaitDesc, prometheus.CounterValue, s.WaitDuration.Seconds(), e.Name)
+	}
+}
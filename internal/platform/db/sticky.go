@@ -0,0 +1,64 @@
+package db
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type stickyContextKey struct{}
+
+type primaryContextKey struct{}
+
+// stickiness remembers the last write made with a context
+type stickiness struct {
+	mu        sync.Mutex
+	lastWrite time.Time
+}
+
+// WithStickiness enables read-your-writes tracking for ctx.
+// Reads made with the returned context go to the primary for the database's
+// sticky window after lastWrite, a write the caller made earlier, or after
+// MarkWrite has been called on it. lastWrite is zero when none is known.
+func WithStickiness(ctx context.Context, lastWrite time.Time) context.Context {
+	return context.WithValue(ctx, stickyContextKey{}, &stickiness{lastWrite: lastWrite})
+}
+
+// LastWrite returns the time of the last write known to ctx, or the zero time
+func LastWrite(ctx context.Context) time.Time {
+	s, ok := ctx.Value(stickyContextKey{}).(*stickiness)
+	if !ok {
+		return time.Time{}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastWrite
+}
+
+// WithPrimary forces every read made with ctx to the primary
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryContextKey{}, true)
+}
+
+// MarkWrite records that ctx has just written; it is a no-op without WithStickiness
+func MarkWrite(ctx context.Context) {
+	if s, ok := ctx.Value(stickyContextKey{}).(*stickiness); ok {
+		s.mu.Lock()
+		s.lastWrite = time.Now()
+		s.mu.Unlock()
+	}
+}
+
+// readFromPrimary reports whether reads made with ctx must see the primary
+func readFromPrimary(ctx context.Context, window time.Duration) bool {
+	if forced, _ := ctx.Value(primaryContextKey{}).(bool); forced {
+		return true
+	}
+	s, ok := ctx.Value(stickyContextKey{}).(*stickiness)
+	if !ok || window <= 0 {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !s.lastWrite.IsZero() && time.Since(s.lastWrite) < window
+}
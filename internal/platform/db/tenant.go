@@ -0,0 +1,42 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Jexim/HelloGo/internal/platform/tenant"
+)
+
+// TenantTx runs fn in a transaction scoped to the tenant in ctx.
+// app.tenant_id is set with SET LOCAL semantics, so Row-Level Security policies see
+// the tenant only for the lifetime of the transaction and the pooled connection is
+// returned without it. A context without a tenant never reaches the database.
+func TenantTx(ctx context.Context, db *sql.DB, opts *sql.TxOptions, fn func(tx *sql.Tx) error) error {
+	return tenantTx(ctx, db, opts, true, fn)
+}
+
+// tenantTx requires a tenant in ctx and sets app.tenant_id when rls is set.
+// Drivers without Row-Level Security leave filtering by tenant to the queries.
+func tenantTx(ctx context.Context, db *sql.DB, opts *sql.TxOptions, rls bool, fn func(tx *sql.Tx) error) error {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return err
+	}
+	tx, err := db.BeginTx(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("begin tenant tx: %w", err)
+	}
+	// set_config(..., true) is SET LOCAL with a bind parameter
+	if rls {
+		if _, err := tx.ExecContext(ctx, "SELECT set_config('app.tenant_id', $1, true)", tenantID); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("set tenant: %w", err)
+		}
+	}
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -2,10 +2,12 @@ package health
 
 import (
 	"context"
-	"database/sql"
 	"time"
 
 	"go.uber.org/zap"
+
+	platformdb "github.com/Jexim/HelloGo/internal/platform/db"
+	"github.com/Jexim/HelloGo/internal/platform/metrics"
 )
 
 type Status struct {
@@ -19,15 +21,22 @@ type HealthStatus struct {
 	Services  map[string]Status `json:"services"`
 }
 
+// Databases is the set of database pools to check
+type Databases interface {
+	Entries() []platformdb.Entry
+}
+
 type Checker struct {
-	db     *sql.DB
-	logger *zap.Logger
+	dbs     Databases
+	metrics *metrics.Metrics
+	logger  *zap.Logger
 }
 
-func NewChecker(db *sql.DB, logger *zap.Logger) *Checker {
+func NewChecker(dbs Databases, m *metrics.Metrics, logger *zap.Logger) *Checker {
 	return &Checker{
-		db:     db,
-		logger: logger,
+		dbs:     dbs,
+		metrics: m,
+		logger:  logger,
 	}
 }
 
@@ -38,22 +47,35 @@ func (c *Checker) Check(ctx context.Context) HealthStatus {
 		Services:  make(map[string]Status),
 	}
 
-	// Check database
-	if err := c.checkDatabase(ctx); err != nil {
-		status.Status = "degraded"
-		status.Services["database"] = Status{
-			Status:  "error",
-			Message: err.Error(),
+	// Check every registry primary and replica separately. The primary of the
+	// default database also keeps the "database" key clients already read;
+	// in-memory storage has no entry and is always up.
+	status.Services["database"] = Status{Status: "ok"}
+	for _, e := range c.dbs.Entries() {
+		var s Status
+		if err := e.DB.PingContext(ctx); err != nil {
+			status.Status = "degraded"
+			// Optional databases are expected to be missing at times
+			state := "error"
+			if e.Optional {
+				state = "unavailable"
+			}
+			s = Status{
+				Status:  state,
+				Message: err.Error(),
+			}
+			c.metrics.DatabaseUp.WithLabelValues(e.Name).Set(0)
+		} else {
+			s = Status{
+				Status: "ok",
+			}
+			c.metrics.DatabaseUp.WithLabelValues(e.Name).Set(1)
 		}
-	} else {
-		status.Services["database"] = Status{
-			Status: "ok",
+		status.Services["database:"+e.Name] = s
+		if e.Name == platformdb.DefaultName {
+			status.Services["database"] = s
 		}
 	}
 
 	return status
 }
-
-func (c *Checker) checkDatabase(ctx context.Context) error {
-	return c.db.PingContext(ctx)
-}
@@ -0,0 +1,24 @@
+package logger
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+const loggerContextKey contextKey = "logger"
+
+// WithContext stores the request-scoped logger l in ctx
+func WithContext(ctx context.Context, l *zap.Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey, l)
+}
+
+// FromContext returns the logger stored by WithContext, made Verbose when ctx
+// asks for debug logs. Without one it returns the global zap logger.
+func FromContext(ctx context.Context) *zap.Logger {
+	l, ok := ctx.Value(loggerContextKey).(*zap.Logger)
+	if !ok {
+		l = zap.L()
+	}
+	return For(ctx, l)
+}
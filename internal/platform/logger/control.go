@@ -0,0 +1,96 @@
+package logger
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Controller changes the runtime level, either for good or temporarily with
+// an automatic revert to the level it was changed from
+type Controller struct {
+	level    zap.AtomicLevel
+	logger   *zap.Logger
+	mu       sync.Mutex
+	base     zapcore.Level
+	revertAt time.Time
+	timer    *time.Timer
+	// gen counts changes, so a revert timer that fired while a later change
+	// held the lock leaves that change alone
+	gen uint64
+}
+
+// LevelState is the current level, the level it reverts to and when
+type LevelState struct {
+	Level    string     `json:"level"`
+	Base     string     `json:"base"`
+	RevertAt *time.Time `json:"revert_at,omitempty"`
+}
+
+func NewController(level zap.AtomicLevel, logger *zap.Logger) *Controller {
+	return &Controller{level: level, logger: logger, base: level.Level()}
+}
+
+// Set changes the level. A positive revertAfter restores the current base level
+// once it passes; zero makes l the new base level.
+func (c *Controller) Set(l zapcore.Level, revertAfter time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stopTimer()
+	c.gen++
+	if revertAfter > 0 {
+		gen := c.gen
+		c.revertAt = time.Now().Add(revertAfter)
+		c.timer = time.AfterFunc(revertAfter, func() { c.revert(gen) })
+	} else {
+		c.base = l
+	}
+	c.level.SetLevel(l)
+	c.logger.Info("log level changed", zap.Stringer("level", l), zap.Duration("revert_after", revertAfter))
+}
+
+// Toggle switches to l for d, or reverts early when a temporary change is active
+func (c *Controller) Toggle(l zapcore.Level, d time.Duration) {
+	c.mu.Lock()
+	active, gen := c.timer != nil, c.gen
+	c.mu.Unlock()
+	if active {
+		c.revert(gen)
+		return
+	}
+	c.Set(l, d)
+}
+
+// State returns the current level and its pending revert
+func (c *Controller) State() LevelState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	state := LevelState{Level: c.level.Level().String(), Base: c.base.String()}
+	if !c.revertAt.IsZero() {
+		at := c.revertAt
+		state.RevertAt = &at
+	}
+	return state
+}
+
+// revert restores the base level unless change gen has been superseded
+func (c *Controller) revert(gen uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gen != c.gen {
+		return
+	}
+	c.stopTimer()
+	c.level.SetLevel(c.base)
+	c.logger.Info("log level reverted", zap.Stringer("level", c.base))
+}
+
+func (c *Controller) stopTimer() {
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+	c.revertAt = time.Time{}
+}
@@ -0,0 +1,33 @@
+package logger
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestControllerIgnoresStaleRevert(t *testing.T) {
+	level := zap.NewAtomicLevelAt(zapcore.InfoLevel)
+	c := NewController(level, zap.NewNop())
+
+	c.Set(zapcore.DebugLevel, time.Hour)
+	stale := c.gen
+	c.Set(zapcore.WarnLevel, 0)
+
+	// A timer of the first change that fired while the second held the lock
+	c.revert(stale)
+	if got := level.Level(); got != zapcore.WarnLevel {
+		t.Fatalf("level = %s after stale revert, want warn", got)
+	}
+
+	c.Set(zapcore.DebugLevel, time.Hour)
+	c.Toggle(zapcore.DebugLevel, time.Hour)
+	if got := level.Level(); got != zapcore.WarnLevel {
+		t.Fatalf("level = %s after toggle, want warn", got)
+	}
+	if s := c.State(); s.RevertAt != nil {
+		t.Fatalf("revert still pending after toggle: %+v", s)
+	}
+}
@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+
+	"github.com/Jexim/HelloGo/internal/platform/config"
+)
+
+// newRotatingFile returns the writer of the file output. Lumberjack rotates
+// it by size and prunes old files; a timer adds the time-based rotation,
+// at every multiple of RotateEvery in UTC until ctx is done. Failed
+// rotations are reported to errOut.
+func newRotatingFile(ctx context.Context, cfg config.LogFileConfig, errOut zapcore.WriteSyncer) (zapcore.WriteSyncer, error) {
+	if cfg.Path == "" {
+		return nil, fmt.Errorf("log output %q needs a path", OutputFile)
+	}
+	if err := os.MkdirAll(filepath.Dir(cfg.Path), 0o755); err != nil {
+		return nil, fmt.Errorf("create log directory: %w", err)
+	}
+	lj := &lumberjack.Logger{
+		Filename:   cfg.Path,
+		MaxSize:    cfg.MaxSizeMB,
+		MaxAge:     int((cfg.MaxAge + 24*time.Hour - 1) / (24 * time.Hour)),
+		MaxBackups: cfg.MaxBackups,
+		Compress:   cfg.Compress,
+	}
+	if d := cfg.RotateEvery; d > 0 {
+		go rotateEvery(ctx, lj, d, errOut)
+	}
+	return zapcore.AddSync(lj), nil
+}
+
+// rotateEvery rotates lj at every multiple of d until ctx is done
+func rotateEvery(ctx context.Context, lj *lumberjack.Logger, d time.Duration, errOut zapcore.WriteSyncer) {
+	for {
+		now := time.Now()
+		timer := time.NewTimer(now.Truncate(d).Add(d).Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		if err := lj.Rotate(); err != nil {
+			fmt.Fprintf(errOut, "%v rotate log file %s: %v\n", time.Now().UTC(), lj.Filename, err)
+			errOut.Sync()
+		}
+	}
+}
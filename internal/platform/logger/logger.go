@@ -1,18 +1,119 @@
 package logger
 
 import (
+	"context"
+	"fmt"
+	"os"
+
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+
+	"github.com/Jexim/HelloGo/internal/platform/config"
+	"github.com/Jexim/HelloGo/internal/platform/redact"
+)
+
+const (
+	EncodingJSON    = "json"
+	EncodingConsole = "console"
+)
+
+const (
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
+	OutputFile   = "file"
+	OutputSyslog = "syslog"
 )
 
-// New creates a zap logger with the provided level string (e.g., "debug", "info").
-func New(levelString string) *zap.Logger {
+// New creates a zap logger writing to the outputs of cfg at cfg.Level.
+// The returned level changes the logger's level at runtime; loggers derived with
+// Verbose log at every level regardless of it. Entries are masked by redactor
+// unless it is nil. Background work of the outputs, such as the time-based
+// rotation of the log file, stops when ctx is done.
+func New(ctx context.Context, cfg config.LoggerConfig, redactor *redact.Redactor) (*zap.Logger, zap.AtomicLevel, error) {
 	level := zapcore.InfoLevel
-	if err := level.Set(levelString); err != nil {
+	if err := level.Set(cfg.Level); err != nil {
 		level = zapcore.InfoLevel
 	}
-	cfgZap := zap.NewProductionConfig()
-	cfgZap.Level = zap.NewAtomicLevelAt(level)
-	logger, _ := cfgZap.Build()
-	return logger
+	atomic := zap.NewAtomicLevelAt(level)
+
+	// Internal errors of zap and of the outputs go to the same place
+	errOut := zapcore.Lock(os.Stderr)
+	enc := zap.NewProductionEncoderConfig()
+	opts := []zap.Option{zap.AddCaller(), zap.AddStacktrace(zapcore.ErrorLevel), zap.ErrorOutput(errOut)}
+	if cfg.Development {
+		enc = zap.NewDevelopmentEncoderConfig()
+		opts = []zap.Option{zap.AddCaller(), zap.AddStacktrace(zapcore.WarnLevel), zap.Development(), zap.ErrorOutput(errOut)}
+	}
+
+	outputs := cfg.Outputs
+	if len(outputs) == 0 {
+		outputs = []string{OutputStderr}
+	}
+	// The output cores accept every level; levelCore applies the atomic level on top
+	cores := make([]zapcore.Core, 0, len(outputs))
+	for _, out := range outputs {
+		c, err := outputCore(ctx, cfg, enc, out, errOut)
+		if err != nil {
+			return nil, atomic, err
+		}
+		cores = append(cores, c)
+	}
+	core := zapcore.NewTee(cores...)
+	if s := cfg.Sampling; s.Enabled && !cfg.Development {
+		core = zapcore.NewSamplerWithOptions(core, s.Tick, s.Initial, s.Thereafter)
+	}
+
+	logger := zap.New(&levelCore{Core: redact.Core(core, redactor), level: atomic}, opts...)
+	return logger, atomic, nil
+}
+
+// outputCore returns the core writing entries to out
+func outputCore(ctx context.Context, cfg config.LoggerConfig, enc zapcore.EncoderConfig, out string, errOut zapcore.WriteSyncer) (zapcore.Core, error) {
+	switch out {
+	case OutputStdout, OutputStderr:
+		f := os.Stdout
+		if out == OutputStderr {
+			f = os.Stderr
+		}
+		e, err := encoder(cfg.Encoding, enc, cfg.Color)
+		if err != nil {
+			return nil, err
+		}
+		return zapcore.NewCore(e, zapcore.Lock(f), zapcore.DebugLevel), nil
+	case OutputFile:
+		e, err := encoder(cfg.Encoding, enc, false)
+		if err != nil {
+			return nil, err
+		}
+		w, err := newRotatingFile(ctx, cfg.File, errOut)
+		if err != nil {
+			return nil, err
+		}
+		return zapcore.NewCore(e, w, zapcore.DebugLevel), nil
+	case OutputSyslog:
+		// Syslog records its own timestamp
+		enc.TimeKey = ""
+		e, err := encoder(cfg.Encoding, enc, false)
+		if err != nil {
+			return nil, err
+		}
+		return newSyslogCore(cfg.Syslog, e)
+	default:
+		return nil, fmt.Errorf("unknown log output %q", out)
+	}
+}
+
+// encoder returns the encoder named encoding; color only applies to console
+func encoder(encoding string, enc zapcore.EncoderConfig, color bool) (zapcore.Encoder, error) {
+	switch encoding {
+	case "", EncodingJSON:
+		return zapcore.NewJSONEncoder(enc), nil
+	case EncodingConsole:
+		if color {
+			enc.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		}
+		return zapcore.NewConsoleEncoder(enc), nil
+	default:
+		return nil, fmt.Errorf("unknown log encoding %q", encoding)
+	}
 }
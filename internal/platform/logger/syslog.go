@@ -0,0 +1,63 @@
+//go:build !windows && !plan9
+
+package logger
+
+import (
+	"fmt"
+	"log/syslog"
+
+	"go.uber.org/zap/zapcore"
+
+	"github.com/Jexim/HelloGo/internal/platform/config"
+)
+
+// syslogCore writes every entry as one syslog message with the severity of its level
+type syslogCore struct {
+	enc zapcore.Encoder
+	w   *syslog.Writer
+}
+
+func newSyslogCore(cfg config.SyslogConfig, enc zapcore.Encoder) (zapcore.Core, error) {
+	w, err := syslog.Dial(cfg.Network, cfg.Address, syslog.LOG_INFO|syslog.LOG_DAEMON, cfg.Tag)
+	if err != nil {
+		return nil, fmt.Errorf("connect to syslog: %w", err)
+	}
+	return &syslogCore{enc: enc, w: w}, nil
+}
+
+func (c *syslogCore) Enabled(zapcore.Level) bool { return true }
+
+func (c *syslogCore) With(fields []zapcore.Field) zapcore.Core {
+	enc := c.enc.Clone()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	return &syslogCore{enc: enc, w: c.w}
+}
+
+func (c *syslogCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return ce.AddCore(ent, c)
+}
+
+func (c *syslogCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	buf, err := c.enc.EncodeEntry(ent, fields)
+	if err != nil {
+		return err
+	}
+	msg := buf.String()
+	buf.Free()
+	switch ent.Level {
+	case zapcore.DebugLevel:
+		return c.w.Debug(msg)
+	case zapcore.InfoLevel:
+		return c.w.Info(msg)
+	case zapcore.WarnLevel:
+		return c.w.Warning(msg)
+	case zapcore.ErrorLevel:
+		return c.w.Err(msg)
+	default:
+		return c.w.Crit(msg)
+	}
+}
+
+func (c *syslogCore) Sync() error { return nil }
@@ -0,0 +1,15 @@
+//go:build windows || plan9
+
+package logger
+
+import (
+	"fmt"
+
+	"go.uber.org/zap/zapcore"
+
+	"github.com/Jexim/HelloGo/internal/platform/config"
+)
+
+func newSyslogCore(config.SyslogConfig, zapcore.Encoder) (zapcore.Core, error) {
+	return nil, fmt.Errorf("log output %q is not supported on this platform", OutputSyslog)
+}
@@ -0,0 +1,64 @@
+package logger
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// levelCore filters entries by a runtime level unless it is verbose
+type levelCore struct {
+	zapcore.Core
+	level   zap.AtomicLevel
+	verbose bool
+}
+
+func (c *levelCore) Enabled(l zapcore.Level) bool {
+	return c.verbose || c.level.Enabled(l)
+}
+
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), level: c.level, verbose: c.verbose}
+}
+
+func (c *levelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
+
+// Verbose returns l logging at every level, whatever the runtime level is.
+// Loggers not created by New are returned unchanged.
+func Verbose(l *zap.Logger) *zap.Logger {
+	return l.WithOptions(zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+		if lc, ok := c.(*levelCore); ok {
+			return &levelCore{Core: lc.Core, level: lc.level, verbose: true}
+		}
+		return c
+	}))
+}
+
+type contextKey string
+
+const verboseContextKey contextKey = "verbose"
+
+// WithVerbose marks ctx as a request to log at every level
+func WithVerbose(ctx context.Context) context.Context {
+	return context.WithValue(ctx, verboseContextKey, true)
+}
+
+// IsVerbose reports whether ctx was marked by WithVerbose
+func IsVerbose(ctx context.Context) bool {
+	v, _ := ctx.Value(verboseContextKey).(bool)
+	return v
+}
+
+// For returns l, made Verbose when ctx asks for it
+func For(ctx context.Context, l *zap.Logger) *zap.Logger {
+	if IsVerbose(ctx) {
+		return Verbose(l)
+	}
+	return l
+}
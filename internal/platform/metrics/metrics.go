@@ -1,46 +1,195 @@
 package metrics
 
 import (
+	"context"
+
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/Jexim/HelloGo/internal/platform/config"
 )
 
-var (
-	// HTTPRequestsTotal tracks total number of HTTP requests
-	HTTPRequestsTotal = promauto.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "http_requests_total",
-			Help: "Total number of HTTP requests",
-		},
-		[]string{"method", "path", "status"},
-	)
+// DefaultSizeBuckets are the buckets of the HTTP size histograms, 100B to 10MB
+var DefaultSizeBuckets = prometheus.ExponentialBuckets(100, 10, 6)
 
+// Metrics holds every metric of the service. The components recording them
+// are handed the instance main creates on its registry.
+type Metrics struct {
+	// HTTPRequestsTotal tracks total number of HTTP requests
+	HTTPRequestsTotal *prometheus.CounterVec
+	// HTTPRequestsInFlight tracks HTTP requests being served
+	HTTPRequestsInFlight prometheus.Gauge
 	// HTTPRequestDuration tracks HTTP request duration
-	HTTPRequestDuration = promauto.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Name:    "http_request_duration_seconds",
-			Help:    "HTTP request duration in seconds",
-			Buckets: prometheus.DefBuckets,
-		},
-		[]string{"method", "path"},
-	)
+	HTTPRequestDuration *prometheus.HistogramVec
+	// HTTPRequestSize tracks the size of HTTP request bodies
+	HTTPRequestSize *prometheus.HistogramVec
+	// HTTPResponseSize tracks the size of HTTP response bodies
+	HTTPResponseSize *prometheus.HistogramVec
 
 	// DatabaseOperations tracks database operations
-	DatabaseOperations = promauto.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "database_operations_total",
-			Help: "Total number of database operations",
-		},
-		[]string{"operation", "status"},
-	)
-
+	DatabaseOperations *prometheus.CounterVec
 	// DatabaseOperationDuration tracks database operation duration
-	DatabaseOperationDuration = promauto.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Name:    "database_operation_duration_seconds",
-			Help:    "Database operation duration in seconds",
-			Buckets: prometheus.DefBuckets,
-		},
-		[]string{"operation"},
+	DatabaseOperationDuration *prometheus.HistogramVec
+	// DatabaseUp reports whether each registry database answered its last health check
+	DatabaseUp *prometheus.GaugeVec
+
+	// CacheHits counts reads answered from a cache
+	CacheHits *prometheus.CounterVec
+	// CacheMisses counts reads that had to load from the backing store
+	CacheMisses *prometheus.CounterVec
+	// CacheEvictions counts entries dropped because the cache was full or they expired
+	CacheEvictions *prometheus.CounterVec
+
+	// RateLimitThrottled counts requests rejected by the rate limiter
+	RateLimitThrottled *prometheus.CounterVec
+
+	// ConcurrencyLimit is the current adaptive limit of concurrent HTTP requests
+	ConcurrencyLimit prometheus.Gauge
+	// ConcurrencyQueueDepth tracks requests waiting for a concurrency slot
+	ConcurrencyQueueDepth *prometheus.GaugeVec
+	// ConcurrencyShed counts requests rejected by the concurrency limiter
+	ConcurrencyShed *prometheus.CounterVec
+}
+
+// New registers the metrics of the service on reg, with the histogram
+// buckets cfg sets, along with the Go runtime and process collectors.
+// Tests pass a fresh registry of their own.
+func New(reg *prometheus.Registry, cfg config.MetricsConfig) *Metrics {
+	reg.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
 	)
-)
+	factory := promauto.With(reg)
+	durations := orDefault(cfg.DurationBuckets, prometheus.DefBuckets)
+	sizes := orDefault(cfg.SizeBuckets, DefaultSizeBuckets)
+
+	return &Metrics{
+		HTTPRequestsTotal: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "http_requests_total",
+				Help: "Total number of HTTP requests",
+			},
+			[]string{"method", "path", "status"},
+		),
+		HTTPRequestsInFlight: factory.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "http_requests_in_flight",
+				Help: "Number of HTTP requests being served",
+			},
+		),
+		HTTPRequestDuration: factory.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "http_request_duration_seconds",
+				Help:    "HTTP request duration in seconds",
+				Buckets: durations,
+			},
+			[]string{"method", "path"},
+		),
+		HTTPRequestSize: factory.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "http_request_size_bytes",
+				Help:    "HTTP request body size in bytes",
+				Buckets: sizes,
+			},
+			[]string{"method", "path"},
+		),
+		HTTPResponseSize: factory.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "http_response_size_bytes",
+				Help:    "HTTP response body size in bytes",
+				Buckets: sizes,
+			},
+			[]string{"method", "path"},
+		),
+		DatabaseOperations: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "database_operations_total",
+				Help: "Total number of database operations",
+			},
+			[]string{"operation", "status"},
+		),
+		DatabaseOperationDuration: factory.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "database_operation_duration_seconds",
+				Help:    "Database operation duration in seconds",
+				Buckets: orDefault(cfg.DatabaseBuckets, prometheus.DefBuckets),
+			},
+			[]string{"operation"},
+		),
+		DatabaseUp: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "database_up",
+				Help: "Whether the database answered its last health check (1) or not (0)",
+			},
+			[]string{"database"},
+		),
+		CacheHits: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "cache_hits_total",
+				Help: "Total number of cache hits",
+			},
+			[]string{"cache"},
+		),
+		CacheMisses: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "cache_misses_total",
+				Help: "Total number of cache misses",
+			},
+			[]string{"cache"},
+		),
+		CacheEvictions: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "cache_evictions_total",
+				Help: "Total number of cache evictions",
+			},
+			[]string{"cache", "reason"},
+		),
+		RateLimitThrottled: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "http_rate_limited_total",
+				Help: "Total number of HTTP requests rejected by the rate limiter",
+			},
+			[]string{"method", "path"},
+		),
+		ConcurrencyLimit: factory.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "http_concurrency_limit",
+				Help: "Current adaptive limit of concurrent HTTP requests",
+			},
+		),
+		ConcurrencyQueueDepth: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "http_concurrency_queue_depth",
+				Help: "Number of HTTP requests waiting for a concurrency slot",
+			},
+			[]string{"priority"},
+		),
+		ConcurrencyShed: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "http_concurrency_shed_total",
+				Help: "Total number of HTTP requests shed by the concurrency limiter",
+			},
+			[]string{"priority"},
+		),
+	}
+}
+
+// Observe records v on o with the trace id of ctx as exemplar when the trace
+// is sampled, so a dashboard can jump from a bucket to a trace
+func Observe(ctx context.Context, o prometheus.Observer, v float64) {
+	sc := trace.SpanContextFromContext(ctx)
+	if eo, ok := o.(prometheus.ExemplarObserver); ok && sc.IsSampled() {
+		eo.ObserveWithExemplar(v, prometheus.Labels{"trace_id": sc.TraceID().String()})
+		return
+	}
+	o.Observe(v)
+}
+
+func orDefault(buckets, def []float64) []float64 {
+	if len(buckets) > 0 {
+		return buckets
+	}
+	return def
+}
@@ -0,0 +1,217 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"io/fs"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/pressly/goose/v3"
+)
+
+// catalogObject is one introspected catalog object, rendered schema-independent
+type catalogObject struct {
+	kind string
+	name string
+	def  string
+}
+
+func (o catalogObject) String() string {
+	return fmt.Sprintf("%s %s: %s", o.kind, o.name, o.def)
+}
+
+// CheckDrift applies the migrations of set and its sqlc schema files to two scratch
+// schemas of the database at dsn, diffs their catalogs and writes the differences to w.
+// See introspect for the objects compared.
+// It reports whether the two agree; scratch schemas are dropped afterwards.
+func CheckDrift(ctx context.Context, dsn string, set Set, w io.Writer) (bool, error) {
+	if set.Schema == nil {
+		return false, fmt.Errorf("module %s has no sqlc schema", set.Module)
+	}
+	suffix := fmt.Sprintf("%s_%d", set.Module, time.Now().UnixNano())
+	migrated, declared := "drift_migrations_"+suffix, "drift_sqlc_"+suffix
+
+	fromMigrations, err := inScratchSchema(ctx, dsn, migrated, func(db *sql.DB) error {
+		p, err := goose.NewProvider(goose.DialectPostgres, db, set.FS, goose.WithDisableVersioning(true))
+		if err != nil {
+			return err
+		}
+		_, err = p.Up(ctx)
+		return err
+	})
+	if err != nil {
+		return false, fmt.Errorf("apply migrations: %w", err)
+	}
+
+	fromSchema, err := inScratchSchema(ctx, dsn, declared, func(db *sql.DB) error {
+		return execSchemaFiles(ctx, db, set.Schema)
+	})
+	if err != nil {
+		return false, fmt.Errorf("apply sqlc schema: %w", err)
+	}
+
+	onlyMigrations, onlySchema := diff(fromMigrations, fromSchema)
+	for _, o := range onlyMigrations {
+		fmt.Fprintf(w, "- %s (migrations only)\n", o)
+	}
+	for _, o := range onlySchema {
+		fmt.Fprintf(w, "+ %s (sqlc schema only)\n", o)
+	}
+	return len(onlyMigrations) == 0 && len(onlySchema) == 0, nil
+}
+
+// inScratchSchema creates schema, runs apply with it as the only search_path entry,
+// introspects the result and drops the schema again
+func inScratchSchema(ctx context.Context, dsn, schema string, apply func(db *sql.DB) error) ([]catalogObject, error) {
+	cc, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		return nil, err
+	}
+	cc.RuntimeParams["search_path"] = schema
+	db := stdlib.OpenDB(*cc)
+	defer db.Close()
+
+	quoted := pgx.Identifier{schema}.Sanitize()
+	if _, err := db.ExecContext(ctx, "CREATE SCHEMA "+quoted); err != nil {
+		return nil, err
+	}
+	defer func() {
+		_, _ = db.ExecContext(context.WithoutCancel(ctx), "DROP SCHEMA "+quoted+" CASCADE")
+	}()
+
+	if err := apply(db); err != nil {
+		return nil, err
+	}
+	return introspect(ctx, db, schema)
+}
+
+// execSchemaFiles runs every .sql file of fsys in name order
+func execSchemaFiles(ctx context.Context, db *sql.DB, fsys fs.FS) error {
+	files, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+	for _, f := range files {
+		body, err := fs.ReadFile(fsys, f)
+		if err != nil {
+			return err
+		}
+		if _, err := db.ExecContext(ctx, string(body)); err != nil {
+			return fmt.Errorf("%s: %w", f, err)
+		}
+	}
+	return nil
+}
+
+// catalogQueries introspect one kind of object each. They select the name and
+// definition of the objects of the schema given as $1.
+var catalogQueries = []struct {
+	kind  string
+	query string
+}{
+	{"column", `
+SELECT table_name || '.' || column_name,
+	data_type
+	|| CASE WHEN is_nullable = 'NO' THEN ' NOT NULL' ELSE '' END
+	|| COALESCE(' DEFAULT ' || column_default, '')
+FROM information_schema.columns
+WHERE table_schema = $1`},
+	{"index", `
+SELECT indexname, indexdef
+FROM pg_indexes
+WHERE schemaname = $1`},
+	{"constraint", `
+SELECT rel.relname || '.' || con.conname, pg_get_constraintdef(con.oid)
+FROM pg_constraint con
+JOIN pg_class rel ON rel.oid = con.conrelid
+JOIN pg_namespace ns ON ns.oid = con.connamespace
+WHERE ns.nspname = $1`},
+	{"row security", `
+SELECT rel.relname,
+	CASE WHEN rel.relforcerowsecurity THEN 'forced'
+		WHEN rel.relrowsecurity THEN 'enabled'
+		ELSE 'disabled' END
+FROM pg_class rel
+JOIN pg_namespace ns ON ns.oid = rel.relnamespace
+WHERE ns.nspname = $1 AND rel.relkind IN ('r', 'p')`},
+	{"policy", `
+SELECT tablename || '.' || policyname,
+	permissive || ' FOR ' || cmd || ' TO ' || array_to_string(roles, ', ')
+	|| COALESCE(' USING (' || qual || ')', '')
+	|| COALESCE(' WITH CHECK (' || with_check || ')', '')
+FROM pg_policies
+WHERE schemaname = $1`},
+	{"sequence", `
+SELECT sequencename,
+	data_type::text
+	|| ' START ' || start_value || ' INCREMENT ' || increment_by
+	|| ' MINVALUE ' || min_value || ' MAXVALUE ' || max_value
+	|| CASE WHEN cycle THEN ' CYCLE' ELSE '' END
+FROM pg_sequences
+WHERE schemaname = $1`},
+}
+
+// introspect lists the columns, indexes, constraints, row security, policies
+// and sequences of schema. Views, functions, triggers, grants and comments are
+// not compared.
+func introspect(ctx context.Context, db *sql.DB, schema string) ([]catalogObject, error) {
+	var objects []catalogObject
+	for _, q := range catalogQueries {
+		rows, err := db.QueryContext(ctx, q.query, schema)
+		if err != nil {
+			return nil, fmt.Errorf("introspect %s: %w", q.kind, err)
+		}
+		for rows.Next() {
+			var name, def string
+			if err := rows.Scan(&name, &def); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			objects = append(objects, catalogObject{kind: q.kind, name: name, def: unqualify(def, schema)})
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		if objects[i].kind != objects[j].kind {
+			return objects[i].kind < objects[j].kind
+		}
+		return objects[i].name < objects[j].name
+	})
+	return objects, nil
+}
+
+// unqualify strips the scratch schema name so both catalogs compare equal
+func unqualify(s, schema string) string {
+	return strings.ReplaceAll(s, schema+".", "")
+}
+
+func diff(a, b []catalogObject) (onlyA, onlyB []catalogObject) {
+	inA := make(map[catalogObject]bool, len(a))
+	for _, o := range a {
+		inA[o] = true
+	}
+	inB := make(map[catalogObject]bool, len(b))
+	for _, o := range b {
+		inB[o] = true
+		if !inA[o] {
+			onlyB = append(onlyB, o)
+		}
+	}
+	for _, o := range a {
+		if !inB[o] {
+			onlyA = append(onlyA, o)
+		}
+	}
+	return onlyA, onlyB
+}
@@ -0,0 +1,81 @@
+package migrate_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/Jexim/HelloGo/internal/modules/hello"
+	"github.com/Jexim/HelloGo/internal/platform/migrate"
+)
+
+const tenantTable = `CREATE TABLE t (id SERIAL PRIMARY KEY, tenant_id TEXT NOT NULL);
+`
+
+const tenantPolicy = `ALTER TABLE t ENABLE ROW LEVEL SECURITY;
+ALTER TABLE t FORCE ROW LEVEL SECURITY;
+CREATE POLICY t_isolation ON t USING (tenant_id = current_setting('app.tenant_id', true));
+`
+
+// TestCheckDrift runs against the Postgres database at TEST_DATABASE_URL and
+// skips when the variable is unset
+func TestCheckDrift(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	migration := func(body string) fstest.MapFS {
+		return fstest.MapFS{"001_t.sql": {Data: []byte("-- +goose Up\n" + body)}}
+	}
+	schema := func(body string) fstest.MapFS {
+		return fstest.MapFS{"001_t.sql": {Data: []byte(body)}}
+	}
+	cases := []struct {
+		name  string
+		set   migrate.Set
+		clean bool
+		want  []string
+	}{
+		{
+			name:  "hello module",
+			set:   hello.Migrations(),
+			clean: true,
+		},
+		{
+			name:  "matching row security",
+			set:   migrate.Set{Module: "match", FS: migration(tenantTable + tenantPolicy), Schema: schema(tenantTable + tenantPolicy)},
+			clean: true,
+		},
+		{
+			name: "row security only in migrations",
+			set:  migrate.Set{Module: "rls", FS: migration(tenantTable + tenantPolicy), Schema: schema(tenantTable)},
+			want: []string{"- row security t: forced (migrations only)", "- policy t.t_isolation:"},
+		},
+		{
+			name: "column only in schema",
+			set:  migrate.Set{Module: "column", FS: migration(tenantTable), Schema: schema(tenantTable + "ALTER TABLE t ADD COLUMN note TEXT;\n")},
+			want: []string{"+ column t.note: text (sqlc schema only)"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var out bytes.Buffer
+			clean, err := migrate.CheckDrift(context.Background(), dsn, c.set, &out)
+			if err != nil {
+				t.Fatalf("CheckDrift: %v", err)
+			}
+			if clean != c.clean {
+				t.Fatalf("clean = %v, want %v; drift:\n%s", clean, c.clean, out.String())
+			}
+			for _, w := range c.want {
+				if !strings.Contains(out.String(), w) {
+					t.Errorf("drift report lacks %q:\n%s", w, out.String())
+				}
+			}
+		})
+	}
+}
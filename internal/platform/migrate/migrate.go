@@ -0,0 +1,172 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"io/fs"
+	"strconv"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/database"
+	"github.com/pressly/goose/v3/lock"
+	"go.uber.org/zap"
+
+	platformdb "github.com/Jexim/HelloGo/internal/platform/db"
+)
+
+// Set is the migrations a module owns and the registry database they target
+type Set struct {
+	Module   string
+	Database string
+	// TenantScoped sets are also applied to every database tenants are routed to
+	TenantScoped bool
+	FS           fs.FS
+	// SQLiteFS holds the migrations for databases using the SQLite driver
+	SQLiteFS fs.FS
+	// Schema holds the sqlc schema files the migrations must agree with
+	Schema fs.FS
+}
+
+// VersionTable is the goose version table of the set, one per module
+func (s Set) VersionTable() string {
+	return "goose_db_version_" + s.Module
+}
+
+// Migrator applies one module's embedded goose migrations to a database.
+// On Postgres every run holds an advisory lock, so replicas starting together
+// apply migrations one at a time.
+type Migrator struct {
+	p      *goose.Provider
+	logger *zap.Logger
+}
+
+// New creates a migrator for db, which is opened with the platformdb driver
+func New(db *sql.DB, driver string, set Set, logger *zap.Logger) (*Migrator, error) {
+	dialect, fsys := database.DialectPostgres, set.FS
+	var opts []goose.ProviderOption
+	switch driver {
+	case "", platformdb.DriverPgx:
+		locker, err := lock.NewPostgresSessionLocker()
+		if err != nil {
+			return nil, fmt.Errorf("create migration lock: %w", err)
+		}
+		opts = append(opts, goose.WithSessionLocker(locker))
+	case platformdb.DriverSQLite:
+		if set.SQLiteFS == nil {
+			return nil, fmt.Errorf("module %s has no SQLite migrations", set.Module)
+		}
+		dialect, fsys = database.DialectSQLite3, set.SQLiteFS
+	default:
+		return nil, fmt.Errorf("module %s: driver %q does not support migrations", set.Module, driver)
+	}
+	store, err := database.NewStore(dialect, set.VersionTable())
+	if err != nil {
+		return nil, fmt.Errorf("create migration store: %w", err)
+	}
+	opts = append(opts, goose.WithStore(store))
+	p, err := goose.NewProvider("", db, fsys, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("create migration provider for %s: %w", set.Module, err)
+	}
+	return &Migrator{p: p, logger: logger.With(zap.String("module", set.Module))}, nil
+}
+
+// Up applies every pending migration
+func (m *Migrator) Up(ctx context.Context) error {
+	results, err := m.p.Up(ctx)
+	m.log(results...)
+	return err
+}
+
+// Down rolls back the most recent migration
+func (m *Migrator) Down(ctx context.Context) error {
+	result, err := m.p.Down(ctx)
+	m.log(result)
+	return err
+}
+
+// Redo rolls back the most recent migration and applies it again
+func (m *Migrator) Redo(ctx context.Context) error {
+	if err := m.Down(ctx); err != nil {
+		return err
+	}
+	result, err := m.p.UpByOne(ctx)
+	m.log(result)
+	return err
+}
+
+// To migrates up or down until version is the current version
+func (m *Migrator) To(ctx context.Context, version int64) error {
+	current, err := m.p.GetDBVersion(ctx)
+	if err != nil {
+		return err
+	}
+	var results []*goose.MigrationResult
+	if version >= current {
+		results, err = m.p.UpTo(ctx, version)
+	} else {
+		results, err = m.p.DownTo(ctx, version)
+	}
+	m.log(results...)
+	return err
+}
+
+// Status writes the state of every migration to w
+func (m *Migrator) Status(ctx context.Context, w io.Writer) error {
+	statuses, err := m.p.Status(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "%-24s %-10s %s\n", "Applied At", "State", "Migration")
+	for _, s := range statuses {
+		applied := "-"
+		if !s.AppliedAt.IsZero() {
+			applied = s.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(w, "%-24s %-10s %s\n", applied, s.State, s.Source.Path)
+	}
+	return nil
+}
+
+// Run executes a migrate subcommand: up, down, status, redo or to VERSION
+func (m *Migrator) Run(ctx context.Context, args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: migrate up|down|status|redo|to VERSION")
+	}
+	switch args[0] {
+	case "up":
+		return m.Up(ctx)
+	case "down":
+		return m.Down(ctx)
+	case "status":
+		return m.Status(ctx, w)
+	case "redo":
+		return m.Redo(ctx)
+	case "to":
+		if len(args) < 2 {
+			return fmt.Errorf("usage: migrate to VERSION")
+		}
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid version %q: %w", args[1], err)
+		}
+		return m.To(ctx, version)
+	default:
+		return fmt.Errorf("unknown migrate command %q", args[0])
+	}
+}
+
+func (m *Migrator) log(results ...*goose.MigrationResult) {
+	for _, r := range results {
+		if r == nil {
+			continue
+		}
+		if r.Error != nil {
+			m.logger.Error("migration failed", zap.String("migration", r.Source.Path), zap.String("direction", r.Direction), zap.Error(r.Error))
+			continue
+		}
+		m.logger.Info("migration applied", zap.String("migration", r.Source.Path), zap.String("direction", r.Direction), zap.Duration("duration", r.Duration))
+	}
+}
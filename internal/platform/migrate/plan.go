@@ -0,0 +1,99 @@
+package migrate
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+
+	"go.uber.org/zap"
+
+	platformdb "github.com/Jexim/HelloGo/internal/platform/db"
+)
+
+// Targets returns the registry databases set applies to under routing
+func Targets(set Set, routing platformdb.Routing) []string {
+	seen := map[string]bool{}
+	name := set.Database
+	if override, ok := routing.Modules[set.Module]; ok {
+		name = override
+	}
+	if name == "" {
+		name = platformdb.DefaultName
+	}
+	seen[name] = true
+	if set.TenantScoped {
+		for _, db := range routing.Tenants {
+			seen[db] = true
+		}
+	}
+	targets := make([]string, 0, len(seen))
+	for db := range seen {
+		targets = append(targets, db)
+	}
+	sort.Strings(targets)
+	return targets
+}
+
+// Runner applies module migration sets to the registry databases they target
+type Runner struct {
+	reg     *platformdb.Registry
+	sets    []Set
+	routing platformdb.Routing
+	logger  *zap.Logger
+}
+
+func NewRunner(reg *platformdb.Registry, sets []Set, routing platformdb.Routing, logger *zap.Logger) *Runner {
+	return &Runner{reg: reg, sets: sets, routing: routing, logger: logger}
+}
+
+// Each calls fn with a migrator for every set and target database, optionally limited to one module
+func (r *Runner) Each(module string, fn func(m *Migrator, set Set, db string) error) error {
+	found := false
+	for _, set := range r.sets {
+		if module != "" && set.Module != module {
+			continue
+		}
+		found = true
+		for _, name := range Targets(set, r.routing) {
+			db := r.reg.Get(name)
+			if db == nil {
+				return fmt.Errorf("module %s: database %s is not configured", set.Module, name)
+			}
+			m, err := New(db, r.reg.Driver(name), set, r.logger.With(zap.String("database", name)))
+			if err != nil {
+				return err
+			}
+			if err := fn(m, set, name); err != nil {
+				return fmt.Errorf("module %s on %s: %w", set.Module, name, err)
+			}
+		}
+	}
+	if !found {
+		return fmt.Errorf("unknown module %q", module)
+	}
+	return nil
+}
+
+// Up applies every pending migration of every module
+func (r *Runner) Up(ctx context.Context) error {
+	return r.Each("", func(m *Migrator, _ Set, _ string) error {
+		return m.Up(ctx)
+	})
+}
+
+// Run executes "[-module NAME] up|down|status|redo|to VERSION" for every
+// matching module and target database
+func (r *Runner) Run(ctx context.Context, args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	fs.SetOutput(w)
+	module := fs.String("module", "", "only migrate this module")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	return r.Each(*module, func(m *Migrator, set Set, db string) error {
+		fmt.Fprintf(w, "== %s on %s (%s)\n", set.Module, db, set.VersionTable())
+		return m.Run(ctx, fs.Args(), w)
+	})
+}
@@ -0,0 +1,69 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often full buckets are dropped from a Memory store
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	limit   Limit
+}
+
+// refill adds the tokens earned since the last take
+func (b *bucket) refill(now time.Time) {
+	b.tokens = math.Min(float64(b.limit.Burst), b.tokens+now.Sub(b.updated).Seconds()*b.limit.Rate)
+	b.updated = now
+}
+
+// Memory keeps buckets in process, so every replica enforces its own limits
+type Memory struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory{buckets: make(map[string]*bucket), now: time.Now}
+}
+
+func (m *Memory) Take(_ context.Context, key string, limit Limit) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	m.sweep(now)
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updated: now}
+		m.buckets[key] = b
+	}
+	b.limit = limit
+	b.refill(now)
+	if b.tokens < 1 {
+		return result(false, b.tokens, limit), nil
+	}
+	b.tokens--
+	return result(true, b.tokens, limit), nil
+}
+
+// sweep drops buckets that have refilled completely, they behave like new ones
+func (m *Memory) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < sweepInterval {
+		return
+	}
+	m.lastSweep = now
+	for key, b := range m.buckets {
+		b.refill(now)
+		if b.tokens >= float64(b.limit.Burst) {
+			delete(m.buckets, key)
+		}
+	}
+}
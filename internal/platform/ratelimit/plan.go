@@ -0,0 +1,22 @@
+package ratelimit
+
+import (
+	"github.com/Jexim/HelloGo/internal/platform/migrate"
+	"github.com/Jexim/HelloGo/internal/platform/ratelimit/migrations"
+)
+
+const (
+	// StoreMemory keeps buckets in process
+	StoreMemory = "memory"
+	// StorePostgres keeps buckets in a registry database shared by all replicas
+	StorePostgres = "postgres"
+)
+
+// Migrations returns the migrations of the Postgres store on database
+func Migrations(database string) migrate.Set {
+	return migrate.Set{
+		Module:   "ratelimit",
+		Database: database,
+		FS:       migrations.FS,
+	}
+}
@@ -0,0 +1,70 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// pruneAfter is how long a bucket may stay untouched before it is deleted; a
+// bucket is always full again long before, so deleting it changes nothing
+const pruneAfter = time.Hour
+
+// take refills and takes from a bucket in one statement, so concurrent
+// replicas never lose each other's updates. The SET expressions all see the
+// old row; $2 is the burst and $3 the rate.
+const take = `-- name: TakeRateLimitToken :one
+INSERT INTO rate_limit_buckets AS b (key, tokens, allowed, updated_at)
+VALUES ($1, $2::float8 - 1, $2::float8 >= 1, clock_timestamp())
+ON CONFLICT (key) DO UPDATE SET
+  tokens = CASE
+    WHEN LEAST($2::float8, b.tokens + EXTRACT(EPOCH FROM clock_timestamp() - b.updated_at)::float8 * $3::float8) >= 1
+    THEN LEAST($2::float8, b.tokens + EXTRACT(EPOCH FROM clock_timestamp() - b.updated_at)::float8 * $3::float8) - 1
+    ELSE LEAST($2::float8, b.tokens + EXTRACT(EPOCH FROM clock_timestamp() - b.updated_at)::float8 * $3::float8)
+  END,
+  allowed = LEAST($2::float8, b.tokens + EXTRACT(EPOCH FROM clock_timestamp() - b.updated_at)::float8 * $3::float8) >= 1,
+  updated_at = clock_timestamp()
+RETURNING tokens, allowed`
+
+const prune = `-- name: PruneRateLimitBuckets :exec
+DELETE FROM rate_limit_buckets WHERE updated_at < clock_timestamp() - make_interval(secs => $1)`
+
+// Postgres keeps buckets in the rate_limit_buckets table so limits hold across replicas
+type Postgres struct {
+	db        *sql.DB
+	logger    *zap.Logger
+	lastPrune atomic.Int64
+}
+
+func NewPostgres(db *sql.DB, logger *zap.Logger) *Postgres {
+	return &Postgres{db: db, logger: logger}
+}
+
+func (p *Postgres) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	p.maybePrune()
+	var tokens float64
+	var allowed bool
+	if err := p.db.QueryRowContext(ctx, take, key, float64(limit.Burst), limit.Rate).Scan(&tokens, &allowed); err != nil {
+		return Result{}, err
+	}
+	return result(allowed, tokens, limit), nil
+}
+
+// maybePrune deletes idle buckets in the background at most once per sweep interval
+func (p *Postgres) maybePrune() {
+	now := time.Now().UnixNano()
+	last := p.lastPrune.Load()
+	if now-last < int64(sweepInterval) || !p.lastPrune.CompareAndSwap(last, now) {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if _, err := p.db.ExecContext(ctx, prune, pruneAfter.Seconds()); err != nil {
+			p.logger.Warn("failed to prune rate limit buckets", zap.Error(err))
+		}
+	}()
+}
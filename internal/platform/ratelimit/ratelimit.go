@@ -0,0 +1,51 @@
+// Package ratelimit implements token buckets shared by the rate limiting middleware.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Limit is a token bucket refilled at Rate tokens per second holding at most Burst tokens
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Result is the outcome of taking a token from a bucket
+type Result struct {
+	Allowed bool
+	// Remaining is the number of whole tokens left in the bucket
+	Remaining int
+	// RetryAfter is how long until a token is available; zero when Allowed
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again
+	Reset time.Duration
+}
+
+// Store keeps the buckets
+type Store interface {
+	// Take removes a token from the bucket of key if it has one
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// result derives a Result from the tokens left after a take
+func result(allowed bool, tokens float64, limit Limit) Result {
+	r := Result{Allowed: allowed, Remaining: int(math.Floor(tokens))}
+	if limit.Rate <= 0 {
+		return r
+	}
+	if !allowed {
+		r.RetryAfter = seconds((1 - tokens) / limit.Rate)
+	}
+	r.Reset = seconds((float64(limit.Burst) - tokens) / limit.Rate)
+	return r
+}
+
+func seconds(s float64) time.Duration {
+	if s <= 0 {
+		return 0
+	}
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,116 @@
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Core wraps c so that every entry is redacted by r before it is encoded:
+// sensitive fields are masked and the message and string values are
+// matched against the patterns. A nil r returns c unchanged.
+func Core(c zapcore.Core, r *Redactor) zapcore.Core {
+	if r == nil {
+		return c
+	}
+	return &core{Core: c, r: r}
+}
+
+type core struct {
+	zapcore.Core
+	r *Redactor
+}
+
+func (c *core) With(fields []zapcore.Field) zapcore.Core {
+	return &core{Core: c.Core.With(c.fields(fields)), r: c.r}
+}
+
+// Check leaves the decision to the wrapped core, so its samplers and the
+// levels of its outputs apply as usual, and writes the entry it accepted
+// once redacted
+func (c *core) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	inner := c.Core.Check(ent, nil)
+	if inner == nil {
+		return ce
+	}
+	return ce.AddCore(ent, &checked{core: c, inner: inner})
+}
+
+func (c *core) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	ent.Message = c.r.String(ent.Message)
+	return c.Core.Write(ent, c.fields(fields))
+}
+
+// checked writes one entry through the cores the wrapped core checked it with
+type checked struct {
+	*core
+	inner *zapcore.CheckedEntry
+}
+
+func (c *checked) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	var out errorOutput
+	c.inner.Entry.Message = c.r.String(ent.Message)
+	c.inner.ErrorOutput = &out
+	c.inner.Write(c.fields(fields)...)
+	return out.err
+}
+
+// errorOutput keeps the write error a checked entry reports
+type errorOutput struct {
+	err error
+}
+
+func (o *errorOutput) Write(p []byte) (int, error) {
+	o.err = errors.New(strings.TrimSpace(string(p)))
+	return len(p), nil
+}
+
+func (o *errorOutput) Sync() error { return nil }
+
+// fields returns a redacted copy of fields
+func (c *core) fields(fields []zapcore.Field) []zapcore.Field {
+	out := make([]zapcore.Field, len(fields))
+	for i, f := range fields {
+		out[i] = c.field(f)
+	}
+	return out
+}
+
+func (c *core) field(f zapcore.Field) zapcore.Field {
+	if c.r.SensitiveField(f.Key) && f.Type != zapcore.SkipType {
+		return zap.String(f.Key, c.r.Mask())
+	}
+	switch f.Type {
+	case zapcore.StringType:
+		f.String = c.r.String(f.String)
+	case zapcore.ByteStringType:
+		return zap.ByteString(f.Key, []byte(c.r.String(string(f.Interface.([]byte)))))
+	case zapcore.ErrorType:
+		if err, ok := f.Interface.(error); ok && err != nil {
+			return zap.String(f.Key, c.r.String(err.Error()))
+		}
+	case zapcore.StringerType:
+		if s, ok := f.Interface.(fmt.Stringer); ok && s != nil {
+			return zap.String(f.Key, c.r.String(s.String()))
+		}
+	case zapcore.ReflectType:
+		// Values are redacted in their JSON form, which is how they are encoded
+		b, err := json.Marshal(f.Interface)
+		if err != nil {
+			return zap.String(f.Key, c.r.String(fmt.Sprintf("%+v", f.Interface)))
+		}
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.UseNumber()
+		var v any
+		if err := dec.Decode(&v); err != nil {
+			return zap.String(f.Key, c.r.String(string(b)))
+		}
+		return zap.Reflect(f.Key, c.r.Value(v))
+	}
+	return f
+}
@@ -0,0 +1,62 @@
+package redact
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestCoreKeepsSampling(t *testing.T) {
+	obs, logs := observer.New(zapcore.InfoLevel)
+	sampled := zapcore.NewSamplerWithOptions(obs, time.Minute, 2, 0)
+	log := zap.New(Core(sampled, newRedactor(t)))
+
+	for i := 0; i < 10; i++ {
+		log.Info("same line", zap.String("password", "hunter2"))
+	}
+	if got := logs.Len(); got != 2 {
+		t.Fatalf("%d entries written, want 2", got)
+	}
+	if got := logs.All()[0].ContextMap()["password"]; got != "***" {
+		t.Fatalf("password = %v, want masked", got)
+	}
+}
+
+func TestCoreRedactsEntries(t *testing.T) {
+	type payload struct {
+		User     string         `json:"user"`
+		Password string         `json:"password"`
+		Meta     map[string]any `json:"meta"`
+	}
+
+	obs, logs := observer.New(zapcore.DebugLevel)
+	log := zap.New(Core(obs, newRedactor(t))).With(zap.String("secret", "s"))
+	log.Info("login by bob@example.com",
+		zap.String("note", "card 4111 1111 1111 1111"),
+		zap.Reflect("body", payload{User: "bob", Password: "hunter2", Meta: map[string]any{"id": 7, "contact": "bob@example.com"}}),
+	)
+
+	entry := logs.All()[0]
+	if entry.Message != "login by ***" {
+		t.Fatalf("message = %q", entry.Message)
+	}
+	fields := entry.ContextMap()
+	if fields["secret"] != "***" {
+		t.Fatalf("secret = %v, want masked", fields["secret"])
+	}
+	if fields["note"] != "card ***" {
+		t.Fatalf("note = %v", fields["note"])
+	}
+	body, ok := fields["body"].(map[string]any)
+	if !ok {
+		t.Fatalf("body = %T, want an object", fields["body"])
+	}
+	meta := body["meta"].(map[string]any)
+	if body["user"] != "bob" || body["password"] != "***" || meta["contact"] != "***" || fmt.Sprint(meta["id"]) != "7" {
+		t.Fatalf("body = %v", body)
+	}
+}
@@ -0,0 +1,238 @@
+// Package redact masks secrets and personal data before they leave the
+// service in logs or error reports.
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/Jexim/HelloGo/internal/platform/config"
+)
+
+// DefaultMask replaces redacted values when the config sets none
+const DefaultMask = "[REDACTED]"
+
+type pattern struct {
+	re *regexp.Regexp
+	// keep leaves the first submatch, such as the "Bearer " of a token, unmasked
+	keep bool
+	// valid filters matches, e.g. card numbers by their check digit
+	valid func(match string) bool
+}
+
+var builtins = map[string]pattern{
+	"bearer": {re: regexp.MustCompile(`(?i)\b((?:bearer|basic)\s+)[A-Za-z0-9\-._~+/]+=*`), keep: true},
+	"jwt":    {re: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)},
+	"email":  {re: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)},
+	"card":   {re: regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`), valid: luhn},
+}
+
+// Redactor masks configured headers, query parameters and fields, and every
+// match of its patterns. A nil Redactor masks nothing.
+type Redactor struct {
+	mask     string
+	headers  map[string]bool
+	params   map[string]bool
+	fields   map[string]bool
+	query    *regexp.Regexp
+	patterns []pattern
+}
+
+// New builds the Redactor of cfg, or returns nil when redaction is disabled
+func New(cfg config.RedactionConfig) (*Redactor, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	r := &Redactor{
+		mask:    cfg.Mask,
+		headers: lowerSet(cfg.Headers),
+		params:  lowerSet(cfg.QueryParams),
+		fields:  lowerSet(cfg.Fields),
+	}
+	if r.mask == "" {
+		r.mask = DefaultMask
+	}
+	if len(cfg.QueryParams) > 0 {
+		names := make([]string, len(cfg.QueryParams))
+		for i, p := range cfg.QueryParams {
+			names[i] = regexp.QuoteMeta(p)
+		}
+		r.query = regexp.MustCompile(`(?i)([?&](?:` + strings.Join(names, "|") + `)=)[^&#\s"']*`)
+	}
+	for _, name := range cfg.Patterns {
+		p, ok := builtins[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown redaction pattern %q", name)
+		}
+		r.patterns = append(r.patterns, p)
+	}
+	for _, expr := range cfg.CustomPatterns {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("redaction pattern %q: %w", expr, err)
+		}
+		r.patterns = append(r.patterns, pattern{re: re})
+	}
+	return r, nil
+}
+
+// Mask returns the replacement of redacted values
+func (r *Redactor) Mask() string {
+	if r == nil {
+		return DefaultMask
+	}
+	return r.mask
+}
+
+// SensitiveHeader reports whether the values of header name are masked
+func (r *Redactor) SensitiveHeader(name string) bool {
+	return r != nil && r.headers[strings.ToLower(name)]
+}
+
+// SensitiveField reports whether the values of the JSON or log field key are
+// masked. Sensitive header names count as fields too.
+func (r *Redactor) SensitiveField(key string) bool {
+	if r == nil {
+		return false
+	}
+	key = strings.ToLower(key)
+	return r.fields[key] || r.headers[key]
+}
+
+// SensitiveParam reports whether the values of query parameter name are masked
+func (r *Redactor) SensitiveParam(name string) bool {
+	return r != nil && r.params[strings.ToLower(name)]
+}
+
+// String masks sensitive query parameters of URLs in s and every pattern match
+func (r *Redactor) String(s string) string {
+	if r == nil || s == "" {
+		return s
+	}
+	if r.query != nil {
+		s = r.query.ReplaceAllString(s, "${1}"+r.mask)
+	}
+	for _, p := range r.patterns {
+		s = r.replace(p, s)
+	}
+	return s
+}
+
+func (r *Redactor) replace(p pattern, s string) string {
+	if !p.keep && p.valid == nil {
+		return p.re.ReplaceAllLiteralString(s, r.mask)
+	}
+	return p.re.ReplaceAllStringFunc(s, func(m string) string {
+		if p.valid != nil && !p.valid(m) {
+			return m
+		}
+		if p.keep {
+			return p.re.FindStringSubmatch(m)[1] + r.mask
+		}
+		return r.mask
+	})
+}
+
+// Header masks value when header name is sensitive, else its patterns
+func (r *Redactor) Header(name, value string) string {
+	if r.SensitiveHeader(name) {
+		return r.mask
+	}
+	return r.String(value)
+}
+
+// Query masks the sensitive parameters of a raw query string such as "a=1&token=x"
+func (r *Redactor) Query(raw string) string {
+	if r == nil || raw == "" {
+		return raw
+	}
+	q, err := url.ParseQuery(raw)
+	if err != nil {
+		return r.String(raw)
+	}
+	for k, vs := range q {
+		for i, v := range vs {
+			if r.SensitiveParam(k) {
+				vs[i] = r.mask
+			} else {
+				vs[i] = r.String(v)
+			}
+		}
+	}
+	return q.Encode()
+}
+
+// JSON masks sensitive fields at any depth of a JSON document and the
+// patterns in its strings. Input that is not JSON is treated as text.
+func (r *Redactor) JSON(b []byte) []byte {
+	if r == nil || len(b) == 0 {
+		return b
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return []byte(r.String(string(b)))
+	}
+	out, err := json.Marshal(r.Value(v))
+	if err != nil {
+		return []byte(r.mask)
+	}
+	return out
+}
+
+// Value masks sensitive map keys and patterns in strings of a decoded JSON value
+func (r *Redactor) Value(v any) any {
+	if r == nil {
+		return v
+	}
+	switch t := v.(type) {
+	case string:
+		return r.String(t)
+	case map[string]any:
+		for k, e := range t {
+			if r.SensitiveField(k) {
+				t[k] = r.mask
+			} else {
+				t[k] = r.Value(e)
+			}
+		}
+	case []any:
+		for i, e := range t {
+			t[i] = r.Value(e)
+		}
+	}
+	return v
+}
+
+func lowerSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, n := range names {
+		set[strings.ToLower(n)] = true
+	}
+	return set
+}
+
+// luhn reports whether the digits of s pass the Luhn check of card numbers
+func luhn(s string) bool {
+	sum, n := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && sum%10 == 0
+}
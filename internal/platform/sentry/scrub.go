@@ -0,0 +1,67 @@
+package sentry
+
+import (
+	"github.com/getsentry/sentry-go"
+
+	"github.com/Jexim/HelloGo/internal/platform/redact"
+)
+
+// beforeSend returns the hook that redacts events with r before they are sent
+func beforeSend(r *redact.Redactor) func(*sentry.Event, *sentry.EventHint) *sentry.Event {
+	return func(event *sentry.Event, _ *sentry.EventHint) *sentry.Event {
+		scrub(event, r)
+		return event
+	}
+}
+
+// scrub masks the request, messages, extras, tags, breadcrumbs and user of event
+func scrub(event *sentry.Event, r *redact.Redactor) {
+	event.Message = r.String(event.Message)
+	for i := range event.Exception {
+		event.Exception[i].Value = r.String(event.Exception[i].Value)
+	}
+	if req := event.Request; req != nil {
+		req.URL = r.String(req.URL)
+		req.QueryString = r.Query(req.QueryString)
+		if req.Cookies != "" {
+			req.Cookies = r.Header("Cookie", req.Cookies)
+		}
+		for k, v := range req.Headers {
+			req.Headers[k] = r.Header(k, v)
+		}
+		for k, v := range req.Env {
+			req.Env[k] = r.String(v)
+		}
+		req.Data = string(r.JSON([]byte(req.Data)))
+	}
+	scrubMap(event.Extra, r)
+	for k, v := range event.Tags {
+		if r.SensitiveField(k) {
+			event.Tags[k] = r.Mask()
+		} else {
+			event.Tags[k] = r.String(v)
+		}
+	}
+	for _, b := range event.Breadcrumbs {
+		b.Message = r.String(b.Message)
+		scrubMap(b.Data, r)
+	}
+	event.User.Email = r.String(event.User.Email)
+	for _, s := range event.Spans {
+		s.Description = r.String(s.Description)
+	}
+}
+
+// scrubMap masks sensitive keys of m and the patterns in its values
+func scrubMap(m map[string]interface{}, r *redact.Redactor) {
+	for k, v := range m {
+		if r.SensitiveField(k) {
+			m[k] = r.Mask()
+			continue
+		}
+		switch t := v.(type) {
+		case string, map[string]any, []any:
+			m[k] = r.Value(t)
+		}
+	}
+}
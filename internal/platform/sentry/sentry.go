@@ -5,16 +5,24 @@ import (
 
 	"github.com/getsentry/sentry-go"
 	"go.uber.org/zap"
+
+	"github.com/Jexim/HelloGo/internal/platform/redact"
 )
 
-// Init initializes Sentry with the given DSN and environment
-func Init(dsn string, environment string, logger *zap.Logger) error {
-	err := sentry.Init(sentry.ClientOptions{
+// Init initializes Sentry with the given DSN and environment. Events and
+// transactions are redacted by redactor before they are sent.
+func Init(dsn string, environment string, redactor *redact.Redactor, logger *zap.Logger) error {
+	opts := sentry.ClientOptions{
 		Dsn:              dsn,
 		TracesSampleRate: 1.0,
 		Environment:      environment,
 		EnableTracing:    true,
-	})
+	}
+	if redactor != nil {
+		opts.BeforeSend = beforeSend(redactor)
+		opts.BeforeSendTransaction = beforeSend(redactor)
+	}
+	err := sentry.Init(opts)
 	if err != nil {
 		logger.Error("sentry initialization failed", zap.Error(err))
 		return err
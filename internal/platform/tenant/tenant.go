@@ -0,0 +1,37 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Jexim/HelloGo/internal/platform/apperr"
+)
+
+var (
+	ErrMissing = fmt.Errorf("%w: tenant is required", apperr.ErrBadRequest)
+)
+
+type contextKey string
+
+const tenantContextKey contextKey = "tenant_id"
+
+// WithTenant stores the tenant id in the context
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantContextKey, tenantID)
+}
+
+// FromContext returns the tenant id stored in the context, or an empty string
+func FromContext(ctx context.Context) string {
+	if s, ok := ctx.Value(tenantContextKey).(string); ok {
+		return s
+	}
+	return ""
+}
+
+// Require returns the tenant id stored in the context or ErrMissing
+func Require(ctx context.Context) (string, error) {
+	if id := FromContext(ctx); id != "" {
+		return id, nil
+	}
+	return "", ErrMissing
+}
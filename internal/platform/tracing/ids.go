@@ -0,0 +1,46 @@
+package tracing
+
+import (
+	"context"
+	"encoding/binary"
+	"math/rand/v2"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type requestedTraceIDKey struct{}
+
+// WithRequestedTraceID asks for root spans started from ctx to use traceID,
+// which continues a trace known only by its id, such as one sent by older
+// clients, without a parent span. The sampler decides as for any root span.
+func WithRequestedTraceID(ctx context.Context, traceID trace.TraceID) context.Context {
+	return context.WithValue(ctx, requestedTraceIDKey{}, traceID)
+}
+
+// idGenerator generates random ids, except for the trace ids requested
+// with WithRequestedTraceID
+type idGenerator struct{}
+
+func (idGenerator) NewIDs(ctx context.Context) (trace.TraceID, trace.SpanID) {
+	if tid, ok := ctx.Value(requestedTraceIDKey{}).(trace.TraceID); ok && tid.IsValid() {
+		return tid, newSpanID()
+	}
+	var tid trace.TraceID
+	for !tid.IsValid() {
+		binary.NativeEndian.PutUint64(tid[:8], rand.Uint64())
+		binary.NativeEndian.PutUint64(tid[8:], rand.Uint64())
+	}
+	return tid, newSpanID()
+}
+
+func (idGenerator) NewSpanID(context.Context, trace.TraceID) trace.SpanID {
+	return newSpanID()
+}
+
+func newSpanID() trace.SpanID {
+	var sid trace.SpanID
+	for !sid.IsValid() {
+		binary.NativeEndian.PutUint64(sid[:], rand.Uint64())
+	}
+	return sid
+}
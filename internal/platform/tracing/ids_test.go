@@ -0,0 +1,48 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestRequestedTraceID(t *testing.T) {
+	requested, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	cases := []struct {
+		name    string
+		sampler sdktrace.Sampler
+		sampled bool
+	}{
+		{"sampled root", sdktrace.AlwaysSample(), true},
+		{"unsampled root", sdktrace.NeverSample(), false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			tp := sdktrace.NewTracerProvider(
+				sdktrace.WithSampler(sdktrace.ParentBased(c.sampler)),
+				sdktrace.WithIDGenerator(idGenerator{}),
+			)
+			ctx := WithRequestedTraceID(context.Background(), requested)
+			_, span := tp.Tracer(TracerName).Start(ctx, "request", trace.WithNewRoot())
+			defer span.End()
+
+			sc := span.SpanContext()
+			if sc.TraceID() != requested {
+				t.Fatalf("trace id = %s, want %s", sc.TraceID(), requested)
+			}
+			if sc.IsSampled() != c.sampled {
+				t.Fatalf("sampled = %v, want %v", sc.IsSampled(), c.sampled)
+			}
+			if ro, ok := span.(sdktrace.ReadOnlySpan); ok && ro.Parent().IsValid() {
+				t.Fatalf("span has parent %s, want a root span", ro.Parent().SpanID())
+			}
+		})
+	}
+
+	tid, sid := idGenerator{}.NewIDs(context.Background())
+	if !tid.IsValid() || !sid.IsValid() || tid == requested {
+		t.Fatalf("NewIDs without a requested trace id = %s, %s", tid, sid)
+	}
+}
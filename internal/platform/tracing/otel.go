@@ -0,0 +1,110 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/Jexim/HelloGo/internal/platform/config"
+)
+
+// TracerName names the tracer of the service's own spans
+const TracerName = "github.com/Jexim/HelloGo"
+
+const (
+	// ExporterNone keeps trace ids and propagation but exports no spans
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+)
+
+// Setup installs the global tracer provider exporting spans as cfg says and
+// the W3C Trace Context and Baggage propagators. The returned func flushes
+// pending spans and stops the exporter.
+func Setup(ctx context.Context, cfg config.TracingConfig) (func(context.Context) error, error) {
+	exp, err := exporter(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("tracing resource: %w", err)
+	}
+
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		// Requests continuing a trace follow the caller's sampling decision
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+		sdktrace.WithIDGenerator(idGenerator{}),
+	}
+	if exp != nil {
+		opts = append(opts, sdktrace.WithBatcher(exp))
+	}
+	tp := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return tp.Shutdown, nil
+}
+
+func exporter(ctx context.Context, cfg config.TracingConfig) (sdktrace.SpanExporter, error) {
+	switch cfg.Exporter {
+	case "", ExporterNone:
+		return nil, nil
+	case ExporterOTLP:
+		opts := []otlptracehttp.Option{otlptracehttp.WithHeaders(cfg.OTLP.Headers)}
+		if strings.Contains(cfg.OTLP.Endpoint, "://") {
+			opts = append(opts, otlptracehttp.WithEndpointURL(cfg.OTLP.Endpoint))
+		} else {
+			opts = append(opts, otlptracehttp.WithEndpoint(cfg.OTLP.Endpoint))
+		}
+		if cfg.OTLP.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		if cfg.OTLP.Timeout > 0 {
+			opts = append(opts, otlptracehttp.WithTimeout(cfg.OTLP.Timeout))
+		}
+		return otlptracehttp.New(ctx, opts...)
+	case ExporterStdout:
+		return stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case ExporterFile:
+		if err := os.MkdirAll(filepath.Dir(cfg.File), 0o755); err != nil {
+			return nil, fmt.Errorf("create trace directory: %w", err)
+		}
+		f, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("open trace file: %w", err)
+		}
+		return stdouttrace.New(stdouttrace.WithWriter(f))
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", cfg.Exporter)
+	}
+}
+
+// Start starts a span as a child of the span in ctx; callers must End it
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(TracerName).Start(ctx, name, opts...)
+}
+
+// End records err on span, marking it failed, and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
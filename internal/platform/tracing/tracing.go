@@ -0,0 +1,28 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type contextKey string
+
+const traceIDContextKey contextKey = "trace_id"
+
+// WithTraceID stores the request trace id in the context
+func WithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDContextKey, traceID)
+}
+
+// TraceID returns the trace id of the span in the context, else the one
+// stored by WithTraceID, or an empty string
+func TraceID(ctx context.Context) string {
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		return sc.TraceID().String()
+	}
+	if s, ok := ctx.Value(traceIDContextKey).(string); ok {
+		return s
+	}
+	return ""
+}